@@ -0,0 +1,106 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BGPSessionStateSpec defines the desired state of BGPSessionState.
+type BGPSessionStateSpec struct {
+}
+
+// BGPSessionStateStatus defines the observed state of BGPSessionState.
+type BGPSessionStateStatus struct {
+	// Node is the node the session belongs to.
+	Node string `json:"node,omitempty"`
+
+	// Peer is the address of the neighbor, or its interface for
+	// unnumbered neighbors.
+	Peer string `json:"peer,omitempty"`
+
+	// PeerGroup is the peer group the neighbor belongs to, if any.
+	// +optional
+	PeerGroup string `json:"peerGroup,omitempty"`
+
+	// Dynamic tells if the neighbor was not configured explicitly, but
+	// accepted because its address belongs to the listen ranges of a
+	// dynamic neighbor.
+	// +optional
+	Dynamic bool `json:"dynamic,omitempty"`
+
+	// VRF is the vrf the session belongs to, as named by FRR (default for the default vrf).
+	// +optional
+	VRF string `json:"vrf,omitempty"`
+
+	// BGPStatus is the state of the BGP session, as reported by FRR
+	// (i.e. Idle, Connect, Active, OpenSent, OpenConfirm, Established).
+	// +optional
+	BGPStatus string `json:"bgpStatus,omitempty"`
+
+	// BFDStatus is the state of the BFD session with the neighbor, or
+	// N/A if BFD is not enabled for it.
+	// +optional
+	BFDStatus string `json:"bfdStatus,omitempty"`
+
+	// PrefixesSent is the number of prefixes advertised to the neighbor.
+	// +optional
+	PrefixesSent int `json:"prefixesSent,omitempty"`
+
+	// PrefixesReceived is the number of prefixes received from the neighbor
+	// and accepted.
+	// +optional
+	PrefixesReceived int `json:"prefixesReceived,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Node",type=string,JSONPath=`.status.node`
+//+kubebuilder:printcolumn:name="Peer",type=string,JSONPath=`.status.peer`
+//+kubebuilder:printcolumn:name="VRF",type=string,JSONPath=`.status.vrf`
+//+kubebuilder:printcolumn:name="BGP",type=string,JSONPath=`.status.bgpStatus`
+//+kubebuilder:printcolumn:name="BFD",type=string,JSONPath=`.status.bfdStatus`
+//+kubebuilder:printcolumn:name="Dynamic",type=boolean,JSONPath=`.status.dynamic`,priority=1
+
+// BGPSessionState exposes the state of a BGP session between a node and
+// one of its neighbors. It is maintained by the daemon running on the node.
+type BGPSessionState struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BGPSessionStateSpec   `json:"spec,omitempty"`
+	Status BGPSessionStateStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// BGPSessionStateList contains a list of BGPSessionState.
+type BGPSessionStateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BGPSessionState `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BGPSessionState{}, &BGPSessionStateList{})
+}
+
+const (
+	// BGPSessionStateNodeLabel is the label holding the name of the node
+	// a BGPSessionState belongs to.
+	BGPSessionStateNodeLabel = "frrk8s.metallb.io/node"
+)
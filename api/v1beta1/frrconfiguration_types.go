@@ -25,8 +25,31 @@ import (
 type FRRConfigurationSpec struct {
 	// +optional
 	BGP BGPConfig `json:"bgp,omitempty"`
-	// TODO node selector
-	// TODO raw config
+
+	// NodeSelector limits the nodes that will attempt to apply this config.
+	// When specified, the configuration will be considered only on nodes
+	// whose labels match the specified selectors.
+	// When it is not specified all nodes will attempt to apply this config.
+	// +optional
+	NodeSelector metav1.LabelSelector `json:"nodeSelector,omitempty"`
+
+	// Raw is a snippet of raw frr configuration that gets appended to the
+	// one rendered translating the type safe API.
+	// +optional
+	Raw RawConfig `json:"raw,omitempty"`
+}
+
+type RawConfig struct {
+	// Priority is the order with this configuration is appended to the
+	// bottom of the rendered configuration. A higher value means the
+	// raw config is appended later in the configuration file.
+	// +optional
+	Priority int `json:"priority,omitempty"`
+
+	// Config is a raw FRR configuration to be appended to the configuration
+	// rendered via the k8s api.
+	// +optional
+	Config string `json:"rawConfig,omitempty"`
 }
 
 type BGPConfig struct {
@@ -36,6 +59,10 @@ type BGPConfig struct {
 	// The list of bfd profiles to be used when configuring the neighbors.
 	// +optional
 	BFDProfiles []BFDProfile `json:"bfdProfiles,omitempty"`
+	// The list of neighbor templates, holding the settings shared by
+	// multiple neighbors.
+	// +optional
+	NeighborTemplates []NeighborTemplate `json:"neighborTemplates,omitempty"`
 }
 
 // Router represent a neighbor router we want FRR to connect to.
@@ -53,19 +80,274 @@ type Router struct {
 	// The list of neighbors we want to establish BGP sessions with.
 	// +optional
 	Neighbors []Neighbor `json:"neighbors,omitempty"`
+	// The list of dynamic neighbors, accepting the sessions opened by any
+	// peer in the given ranges.
+	// +optional
+	DynamicNeighbors []DynamicNeighbor `json:"dynamicNeighbors,omitempty"`
 	// The list of prefixes we want to advertise from this router instance.
 	// +optional
 	Prefixes []string `json:"prefixes,omitempty"`
+	// The list of aggregates announced by this router instance, summarizing
+	// the prefixes it advertises. Each aggregate must cover at least one
+	// of the prefixes of the router, and is announced to the neighbors
+	// the prefixes are advertised to with the "all" mode.
+	// +optional
+	Aggregates []Aggregate `json:"aggregates,omitempty"`
+	// The list of the kinds of routes of the node redistributed by this
+	// router instance, allowing to announce the addresses assigned by other
+	// tooling. The redistributed routes are advertised to the neighbors of
+	// the same configuration the prefixes are advertised to with the "all" mode.
+	// +optional
+	Redistribute []Redistribute `json:"redistribute,omitempty"`
+	// GracefulRestart holds the graceful restart settings of the router,
+	// applied to all its neighbors unless overridden.
+	// +optional
+	GracefulRestart GracefulRestart `json:"gracefulRestart,omitempty"`
+	// Imports is the list of vrfs to leak the routes from into the vrf
+	// of the router.
+	// +optional
+	Imports []Import `json:"imports,omitempty"`
+	// RouteTargets holds the route distinguisher and the route targets used
+	// to leak the routes to and from the other vrfs via the VPN tables.
+	// +optional
+	RouteTargets RouteTargets `json:"routeTargets,omitempty"`
+	// EVPN holds the settings of the l2vpn evpn address family of the router.
+	// +optional
+	EVPN EVPN `json:"evpn,omitempty"`
+	// Multipath holds the equal cost multipath settings of the router.
+	// +optional
+	Multipath Multipath `json:"multipath,omitempty"`
+}
+
+// Aggregate represents a prefix announced in place of (or together with)
+// the more specific prefixes it covers.
+type Aggregate struct {
+	// Prefix is the aggregate prefix.
+	Prefix string `json:"prefix"`
+
+	// SummaryOnly suppresses the announcement of the more specific prefixes
+	// covered by the aggregate.
+	// +optional
+	SummaryOnly bool `json:"summaryOnly,omitempty"`
+
+	// ASSet makes the aggregate carry the set of the ASes found in the as paths
+	// of the prefixes it covers, preventing the loops the summarization could
+	// otherwise hide.
+	// +optional
+	ASSet bool `json:"asSet,omitempty"`
+}
+
+// Redistribute represents the redistribution into BGP of the routes of the
+// node of a given kind, for both the ip families, together with the
+// attributes set on them.
+type Redistribute struct {
+	// Type is the kind of the routes to redistribute. It must be unique
+	// within the router.
+	Type RedistributeType `json:"type"`
+
+	// PrefixSelectors restricts the redistributed routes to the ones matched
+	// by any of the selectors. When empty, all the routes of the given kind
+	// are redistributed.
+	// +optional
+	PrefixSelectors []PrefixSelector `json:"prefixSelectors,omitempty"`
+
+	// LocalPref is the local preference set on the redistributed routes.
+	// +kubebuilder:validation:Maximum=4294967295
+	// +optional
+	LocalPref uint32 `json:"localPref,omitempty"`
+
+	// MED is the multi-exit discriminator set on the redistributed routes.
+	// +kubebuilder:validation:Maximum=4294967295
+	// +optional
+	MED *uint32 `json:"med,omitempty"`
+
+	// Origin is the origin attribute set on the redistributed routes. When
+	// not set, FRR marks them as incomplete.
+	// +kubebuilder:validation:Enum=igp;egp;incomplete
+	// +optional
+	Origin string `json:"origin,omitempty"`
+
+	// Communities are the BGP communities set on the redistributed routes,
+	// in the same forms of the ones associated to the advertised prefixes.
+	// +optional
+	Communities []string `json:"communities,omitempty"`
+}
+
+// Multipath represents the equal cost multipath settings of a router, allowing
+// to install multiple paths for the same prefix, i.e. when it is advertised by
+// more than one ToR.
+type Multipath struct {
+	// MaximumPathsEBGP is the maximum number of paths learned via eBGP installed
+	// for the same prefix. When not set, FRR's default is used.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=64
+	// +optional
+	MaximumPathsEBGP uint32 `json:"maximumPathsEBGP,omitempty"`
+
+	// MaximumPathsIBGP is the maximum number of paths learned via iBGP installed
+	// for the same prefix. When not set, FRR's default is used.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=64
+	// +optional
+	MaximumPathsIBGP uint32 `json:"maximumPathsIBGP,omitempty"`
+
+	// ASPathMultipathRelax allows the paths received from different ASes
+	// to be used together, as long as their as paths have the same length.
+	// +optional
+	ASPathMultipathRelax bool `json:"asPathMultipathRelax,omitempty"`
+}
+
+// EVPN represents the l2vpn evpn address family settings of a router.
+// When either AdvertiseAllVNI or VNIs are set, all the neighbors of the
+// router are activated in the l2vpn evpn address family.
+type EVPN struct {
+	// AdvertiseAllVNI enables the advertisement of all the VNIs configured
+	// on the node. Allowed only on the router of the default vrf.
+	// +optional
+	AdvertiseAllVNI bool `json:"advertiseAllVNI,omitempty"`
+
+	// VNIs holds the route distinguisher and the route targets of specific
+	// VNIs, overriding the automatically derived ones. Allowed only on the
+	// router of the default vrf.
+	// +optional
+	VNIs []VNI `json:"vnis,omitempty"`
+
+	// AdvertiseIPv4Unicast enables advertising the ipv4 routes of the vrf
+	// of the router as EVPN type-5 routes.
+	// +optional
+	AdvertiseIPv4Unicast bool `json:"advertiseIPv4Unicast,omitempty"`
+
+	// AdvertiseIPv6Unicast enables advertising the ipv6 routes of the vrf
+	// of the router as EVPN type-5 routes.
+	// +optional
+	AdvertiseIPv6Unicast bool `json:"advertiseIPv6Unicast,omitempty"`
+
+	// RD is the route distinguisher of the type-5 routes of the vrf.
+	// +optional
+	RD string `json:"rd,omitempty"`
+
+	// ImportRTs is the list of route targets of the type-5 routes to
+	// import into the vrf.
+	// +optional
+	ImportRTs []string `json:"importRTs,omitempty"`
+
+	// ExportRTs is the list of route targets the type-5 routes of the vrf
+	// are tagged with.
+	// +optional
+	ExportRTs []string `json:"exportRTs,omitempty"`
+}
+
+// VNI represents the EVPN settings of a VXLAN network identifier. The
+// route distinguisher and the route targets are in the AS:value or
+// IP:value forms.
+type VNI struct {
+	// VNI is the VXLAN network identifier.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=16777215
+	VNI uint32 `json:"vni"`
+
+	// RD is the route distinguisher of the routes of the VNI.
+	// +optional
+	RD string `json:"rd,omitempty"`
+
+	// ImportRTs is the list of route targets of the routes to import into the VNI.
+	// +optional
+	ImportRTs []string `json:"importRTs,omitempty"`
+
+	// ExportRTs is the list of route targets the routes of the VNI are tagged with.
+	// +optional
+	ExportRTs []string `json:"exportRTs,omitempty"`
+}
+
+// Import represents the routes to leak from another vrf.
+type Import struct {
+	// VRF is the vrf to import the routes from, the default one being
+	// referred to as "default".
+	VRF string `json:"vrf"`
+
+	// PrefixSelectors restricts the imported routes to the ones matching
+	// any of the selectors. When empty, all the routes of the vrf are imported.
+	// +optional
+	PrefixSelectors []PrefixSelector `json:"prefixSelectors,omitempty"`
+}
+
+// RouteTargets represents the route distinguisher and the route targets
+// of the routes exported to and imported from the VPN tables. They are
+// all in the AS:value or IP:value forms.
+type RouteTargets struct {
+	// RD is the route distinguisher of the exported routes. It is
+	// required to export the routes.
+	// +optional
+	RD string `json:"rd,omitempty"`
+
+	// Import is the list of route targets of the routes to import.
+	// +optional
+	Import []string `json:"import,omitempty"`
+
+	// Export is the list of route targets the exported routes are tagged with.
+	// +optional
+	Export []string `json:"export,omitempty"`
+}
+
+// GracefulRestart represents the BGP graceful restart settings, as per RFC4724.
+type GracefulRestart struct {
+	// Mode is the graceful restart mode. When not set, FRR's default (helper)
+	// is used.
+	// +optional
+	Mode GracefulRestartMode `json:"mode,omitempty"`
+
+	// RestartTime is the time the peers are requested to wait for the
+	// sessions to be established again after a restart, before removing
+	// the routes received from the router. Must be lower than 4096s.
+	// +optional
+	RestartTime metav1.Duration `json:"restartTime,omitempty"`
+
+	// StalePathTime is the time the routes of a restarted peer are retained
+	// if the peer does not send the end of RIB marker. Must be lower than 4096s.
+	// +optional
+	StalePathTime metav1.Duration `json:"stalePathTime,omitempty"`
 }
 
 type Neighbor struct {
-	// AS number to use for the local end of the session.
+	// AS number to use for the remote end of the session.
+	// ASN and DynamicASN are mutually exclusive and one of them must be specified.
 	// +kubebuilder:validation:Minimum=0
 	// +kubebuilder:validation:Maximum=4294967295
-	ASN uint32 `json:"asn"`
+	// +optional
+	ASN uint32 `json:"asn,omitempty"`
+
+	// DynamicASN detects the AS number to use for the remote end of the session
+	// without explicitly setting it via the ASN field. Limited to:
+	// internal - if the neighbor's ASN is different than the router's the connection is denied.
+	// external - if the neighbor's ASN is the same as the router's the connection is denied.
+	// ASN and DynamicASN are mutually exclusive and one of them must be specified,
+	// either in the neighbor or in its template.
+	// +optional
+	DynamicASN DynamicASNMode `json:"dynamicASN,omitempty"`
 
 	// The IP address to establish the session with.
-	Address string `json:"address"`
+	// Address and Interface are mutually exclusive and one of them must be specified.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Interface is the node interface over which the unnumbered BGP peering will
+	// be established. The address of the neighbor is discovered via the IPv6
+	// link-local address of the interface, and both the ipv4 and the ipv6
+	// families are exchanged over the session.
+	// Address and Interface are mutually exclusive and one of them must be specified.
+	// +optional
+	Interface string `json:"interface,omitempty"`
+
+	// Template is the name of the neighbor template the neighbor inherits
+	// its settings from. The settings specified in the neighbor override
+	// the ones of the template, field by field.
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// UpdateSource is the local source of the session. If not set, the
+	// source is chosen by the kernel.
+	// +optional
+	UpdateSource UpdateSource `json:"updateSource,omitempty"`
 
 	// Port to dial when establishing the session.
 	// +optional
@@ -81,23 +363,42 @@ type Neighbor struct {
 	// +optional
 	PasswordSecret v1.SecretReference `json:"password,omitempty"`
 
-	// Requested BGP hold time, per RFC4271.
+	// Requested BGP hold time, per RFC4271. It must be at least 3s, and
+	// a whole number of seconds.
+	// When only one of holdTime and keepaliveTime is set, the other
+	// one is derived from it so that the hold time is three times
+	// the keepalive time.
 	// +optional
 	HoldTime metav1.Duration `json:"holdTime,omitempty"`
 
-	// Requested BGP keepalive time, per RFC4271.
+	// Requested BGP keepalive time, per RFC4271. It must be lower
+	// than the hold time, and a whole number of seconds.
 	// +optional
 	KeepaliveTime metav1.Duration `json:"keepaliveTime,omitempty"`
 
-	// To set if the BGPPeer is multi-hops away.
+	// To set if the BGPPeer is multi-hops away. When set, it overrides
+	// the one of the template, also to disable it.
 	// +optional
-	EBGPMultiHop bool `json:"ebgpMultiHop,omitempty"`
+	EBGPMultiHop *bool `json:"ebgpMultiHop,omitempty"`
 
 	// The name of the BFD Profile to be used for the BFD session associated
 	// to the BGP session. If not set, the BFD session won't be set up.
+	// The profile must be defined in the bfdProfiles of the same configuration.
 	// +optional
 	BFDProfile string `json:"bfdProfile,omitempty"`
 
+	// GracefulRestartMode overrides the graceful restart mode of the router
+	// for the session. The restart and the stale path times can be set
+	// only on the router, as FRR does not support them per neighbor.
+	// +optional
+	GracefulRestartMode GracefulRestartMode `json:"gracefulRestartMode,omitempty"`
+
+	// MaximumPrefixes limits the number of prefixes accepted from the neighbor,
+	// per ip family, protecting the node from a peer flooding it with routes.
+	// By default the session is closed when a limit is exceeded.
+	// +optional
+	MaximumPrefixes []MaximumPrefix `json:"maximumPrefixes,omitempty"`
+
 	// ToAdvertise represents the list of prefixes to advertise to the given neighbor
 	// and the associated properties.
 	// +optional
@@ -108,6 +409,169 @@ type Neighbor struct {
 	ToReceive Receive `json:"toReceive,omitempty"`
 }
 
+// UpdateSource represents the local source of a session. Address, Interface
+// and NodeAnnotation are mutually exclusive.
+type UpdateSource struct {
+	// Address is the local address of the session. Its ip family must match
+	// the neighbor's.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Interface is the node interface the local address of the session
+	// is taken from, among the ones of the same ip family of the neighbor.
+	// +optional
+	Interface string `json:"interface,omitempty"`
+
+	// NodeAnnotation is the annotation of the node holding the local address
+	// of the session, allowing to use a different one on each node. The
+	// annotation may contain a comma separated list of addresses, the one
+	// of the same ip family of the neighbor being used.
+	// +optional
+	NodeAnnotation string `json:"nodeAnnotation,omitempty"`
+}
+
+// MaximumPrefix is the limit of the prefixes accepted from a neighbor for
+// an ip family. WarningOnly and RestartAfter are mutually exclusive.
+type MaximumPrefix struct {
+	// IPFamily is the ip family the limit applies to.
+	// +kubebuilder:validation:Enum=ipv4;ipv6
+	IPFamily string `json:"ipFamily"`
+
+	// Limit is the maximum number of prefixes accepted from the neighbor.
+	// +kubebuilder:validation:Minimum=1
+	Limit uint32 `json:"limit"`
+
+	// WarningOnly makes FRR only log a warning when the limit is exceeded,
+	// instead of closing the session.
+	// +optional
+	WarningOnly bool `json:"warningOnly,omitempty"`
+
+	// RestartAfter is the time after which a session closed because of the
+	// limit is established again. It must be expressed in minutes, between
+	// 1 and 65535. When not set, the session stays down until it is cleared.
+	// +optional
+	RestartAfter metav1.Duration `json:"restartAfter,omitempty"`
+}
+
+// NeighborTemplate holds settings that can be shared by multiple neighbors,
+// referencing it by name. The session related ones (timers, password and
+// bfd profile) are rendered as a FRR peer-group.
+type NeighborTemplate struct {
+	// Name is the name the template is referenced by. It must be unique
+	// within the configuration.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_-]+$`
+	Name string `json:"name"`
+
+	// AS number to use for the remote end of the sessions.
+	// ASN and DynamicASN are mutually exclusive.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4294967295
+	// +optional
+	ASN uint32 `json:"asn,omitempty"`
+
+	// DynamicASN detects the AS number to use for the remote end of the sessions.
+	// ASN and DynamicASN are mutually exclusive.
+	// +optional
+	DynamicASN DynamicASNMode `json:"dynamicASN,omitempty"`
+
+	// passwordSecret is name of the authentication secret for the neighbors.
+	// the secret must be of type "kubernetes.io/basic-auth", and created in the
+	// same namespace as the frr-k8s daemon. The password is stored in the
+	// secret as the key "password".
+	// +optional
+	PasswordSecret v1.SecretReference `json:"password,omitempty"`
+
+	// Requested BGP hold time, per RFC4271.
+	// +optional
+	HoldTime metav1.Duration `json:"holdTime,omitempty"`
+
+	// Requested BGP keepalive time, per RFC4271.
+	// +optional
+	KeepaliveTime metav1.Duration `json:"keepaliveTime,omitempty"`
+
+	// To set if the neighbors are multi-hops away, unless they specify
+	// it themselves.
+	// +optional
+	EBGPMultiHop bool `json:"ebgpMultiHop,omitempty"`
+
+	// The name of the BFD Profile to be used for the BFD sessions associated
+	// to the BGP sessions.
+	// +optional
+	BFDProfile string `json:"bfdProfile,omitempty"`
+
+	// ToAdvertise represents the list of prefixes to advertise to the neighbors
+	// and the associated properties. It is used only by the neighbors not
+	// specifying their own.
+	// +optional
+	ToAdvertise Advertise `json:"toAdvertise,omitempty"`
+
+	// ToReceive represents the list of prefixes to receive from the neighbors.
+	// It is used only by the neighbors not specifying their own.
+	// +optional
+	ToReceive Receive `json:"toReceive,omitempty"`
+}
+
+// DynamicNeighbor represents a group of neighbors that are not known in
+// advance, from which incoming sessions are accepted as long as their
+// address belongs to one of the listen ranges. All the neighbors share the
+// same settings.
+type DynamicNeighbor struct {
+	// Name is the name of the peer group the neighbors are part of. It must
+	// be unique within the router.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9_-]+$`
+	Name string `json:"name"`
+
+	// ListenRanges are the subnets the neighbors can open sessions from.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Format="cidr"
+	ListenRanges []string `json:"listenRanges"`
+
+	// AS number to use for the remote end of the sessions.
+	// ASN and DynamicASN are mutually exclusive and one of them must be specified.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4294967295
+	// +optional
+	ASN uint32 `json:"asn,omitempty"`
+
+	// DynamicASN detects the AS number to use for the remote end of the sessions.
+	// ASN and DynamicASN are mutually exclusive and one of them must be specified.
+	// +optional
+	DynamicASN DynamicASNMode `json:"dynamicASN,omitempty"`
+
+	// passwordSecret is name of the authentication secret for the neighbors.
+	// the secret must be of type "kubernetes.io/basic-auth", and created in the
+	// same namespace as the frr-k8s daemon. The password is stored in the
+	// secret as the key "password".
+	// +optional
+	PasswordSecret v1.SecretReference `json:"password,omitempty"`
+
+	// Requested BGP hold time, per RFC4271.
+	// +optional
+	HoldTime metav1.Duration `json:"holdTime,omitempty"`
+
+	// Requested BGP keepalive time, per RFC4271.
+	// +optional
+	KeepaliveTime metav1.Duration `json:"keepaliveTime,omitempty"`
+
+	// To set if the neighbors are multi-hops away.
+	// +optional
+	EBGPMultiHop bool `json:"ebgpMultiHop,omitempty"`
+
+	// The name of the BFD Profile to be used for the BFD sessions associated
+	// to the BGP sessions.
+	// +optional
+	BFDProfile string `json:"bfdProfile,omitempty"`
+
+	// ToAdvertise represents the list of prefixes to advertise to the neighbors
+	// and the associated properties.
+	// +optional
+	ToAdvertise Advertise `json:"toAdvertise,omitempty"`
+
+	// ToReceive represents the list of prefixes to receive from the neighbors.
+	// +optional
+	ToReceive Receive `json:"toReceive,omitempty"`
+}
+
 type Advertise struct {
 	// Prefixes is the list of prefixes allowed to be propagated to
 	// this neighbor. They must match the prefixes defined in the router.
@@ -124,18 +588,60 @@ type Advertise struct {
 	// must be in the prefixes allowed to be advertised.
 	// +optional
 	PrefixesWithCommunity []CommunityPrefixes `json:"withCommunity,omitempty"`
+
+	// ASPathPrepend is prepended to the AS path of all the prefixes
+	// advertised to this neighbor, making them less preferred.
+	// +optional
+	ASPathPrepend ASPathPrepend `json:"asPathPrepend,omitempty"`
+
+	// PrefixesWithASPathPrepend is a list of prefixes whose AS path is
+	// prepended when being advertised, overriding ASPathPrepend. The prefixes
+	// must be in the prefixes allowed to be advertised.
+	// +optional
+	PrefixesWithASPathPrepend []ASPathPrependPrefixes `json:"withASPathPrepend,omitempty"`
+
+	// MED is the multi-exit discriminator set on all the prefixes
+	// advertised to this neighbor.
+	// +kubebuilder:validation:Maximum=4294967295
+	// +optional
+	MED *uint32 `json:"med,omitempty"`
+
+	// PrefixesWithMED is a list of prefixes that are associated to a
+	// multi-exit discriminator when being advertised, overriding MED.
+	// The prefixes must be in the prefixes allowed to be advertised.
+	// +optional
+	PrefixesWithMED []MEDPrefixes `json:"withMED,omitempty"`
+
+	// Origin is the origin attribute set on all the prefixes advertised
+	// to this neighbor.
+	// +kubebuilder:validation:Enum=igp;egp;incomplete
+	// +optional
+	Origin string `json:"origin,omitempty"`
+
+	// PrefixesWithOrigin is a list of prefixes that are associated to an
+	// origin when being advertised, overriding Origin. The prefixes must
+	// be in the prefixes allowed to be advertised.
+	// +optional
+	PrefixesWithOrigin []OriginPrefixes `json:"withOrigin,omitempty"`
 }
 
 type Receive struct {
 	// Prefixes is the list of prefixes allowed to be received from
-	// this neighbor.
+	// this neighbor. When the mode is "all", every prefix sent by the
+	// neighbor is accepted. By default, all the prefixes are rejected.
 	// +optional
 	Allowed AllowedPrefixes `json:"allowed,omitempty"`
 }
 
 type AllowedPrefixes struct {
+	// Prefixes is a list of prefixes, each matching exactly the given prefix.
 	// +kubebuilder:validation:Format="cidr"
 	Prefixes []string `json:"prefixes,omitempty"`
+	// PrefixSelectors is a list of prefixes, each matching a range of prefix
+	// lengths within the given prefix. When advertising, all the prefixes of the
+	// router matching a selector are advertised.
+	// +optional
+	PrefixSelectors []PrefixSelector `json:"prefixSelectors,omitempty"`
 	// Mode is the mode to use when handling the prefixes.
 	// When set to "filtered", only the prefixes in the given list will be allowed.
 	// When set to "all", all the prefixes configured on the router will be allowed.
@@ -143,20 +649,90 @@ type AllowedPrefixes struct {
 	Mode AllowMode `json:"mode,omitempty"`
 }
 
+// PrefixSelector matches all the prefixes contained in Prefix whose length
+// is between GE and LE. When neither is set, only Prefix itself is matched.
+type PrefixSelector struct {
+	// +kubebuilder:validation:Format="cidr"
+	Prefix string `json:"prefix"`
+	// The prefix length to match with must be less than or equal to this value.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=128
+	// +optional
+	LE uint32 `json:"le,omitempty"`
+	// The prefix length to match with must be greater than or equal to this value.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=128
+	// +optional
+	GE uint32 `json:"ge,omitempty"`
+}
+
 type LocalPrefPrefixes struct {
 	// Prefixes is the list of prefixes associated to the local preference.
 	// +kubebuilder:validation:MinItems=1
 	// +kubebuilder:validation:Format="cidr"
-	Prefixes  []string `json:"prefixes,omitempty"`
-	LocalPref int      `json:"localPref,omitempty"`
+	Prefixes []string `json:"prefixes,omitempty"`
+	// LocalPref is the local preference set on the prefixes when
+	// advertising them.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=4294967295
+	LocalPref int `json:"localPref,omitempty"`
 }
 
 type CommunityPrefixes struct {
 	// Prefixes is the list of prefixes associated to the community.
 	// +kubebuilder:validation:MinItems=1
 	// +kubebuilder:validation:Format="cidr"
-	Prefixes  []string `json:"prefixes,omitempty"`
-	Community string   `json:"community,omitempty"`
+	Prefixes []string `json:"prefixes,omitempty"`
+	// Community is the BGP community set on the prefixes when advertising
+	// them. It can be either a well known community name (i.e. no-export)
+	// or in the AS:value form, where both the parts are 16 bits values.
+	// Large communities (RFC8092) are expressed as large:global:local1:local2,
+	// with all the parts being 32 bits values, and extended communities as
+	// rt:value (route target) or soo:value (site of origin), where value is
+	// in the AS:number or IP:number form.
+	Community string `json:"community,omitempty"`
+}
+
+type ASPathPrepend struct {
+	// ASN is the AS number to prepend. When not set, the AS number of the
+	// router is used.
+	// +kubebuilder:validation:Maximum=4294967295
+	// +optional
+	ASN uint32 `json:"asn,omitempty"`
+	// Times is the number of times the AS number is prepended.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	Times int `json:"times,omitempty"`
+}
+
+type ASPathPrependPrefixes struct {
+	// Prefixes is the list of prefixes whose AS path is prepended.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Format="cidr"
+	Prefixes []string `json:"prefixes,omitempty"`
+
+	ASPathPrepend `json:",inline"`
+}
+
+type MEDPrefixes struct {
+	// Prefixes is the list of prefixes associated to the multi-exit discriminator.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Format="cidr"
+	Prefixes []string `json:"prefixes,omitempty"`
+	// MED is the multi-exit discriminator set on the prefixes when advertising them.
+	// +kubebuilder:validation:Maximum=4294967295
+	MED uint32 `json:"med"`
+}
+
+type OriginPrefixes struct {
+	// Prefixes is the list of prefixes associated to the origin.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Format="cidr"
+	Prefixes []string `json:"prefixes,omitempty"`
+	// Origin is the origin set on the prefixes when advertising them.
+	// +kubebuilder:validation:Enum=igp;egp;incomplete
+	Origin string `json:"origin"`
 }
 
 type BFDProfile struct {
@@ -223,8 +799,36 @@ type BFDProfile struct {
 
 // FRRConfigurationStatus defines the observed state of FRRConfiguration.
 type FRRConfigurationStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Nodes contains the outcome of applying the configuration on each
+	// node selected by it, as reported by the daemon running on the node.
+	// +optional
+	// +listType=map
+	// +listMapKey=node
+	Nodes []NodeStatus `json:"nodes,omitempty"`
+}
+
+// NodeStatus represents the outcome of applying the configuration on a given node.
+type NodeStatus struct {
+	// Node is the name of the node this status refers to.
+	Node string `json:"node"`
+
+	// LastAppliedGeneration is the generation of the configuration that was
+	// last applied successfully on the node.
+	// +optional
+	LastAppliedGeneration int64 `json:"lastAppliedGeneration,omitempty"`
+
+	// LastError is the error that prevented the configuration from being
+	// applied on the node, either when translating it, when merging it with
+	// the other configurations or when reloading FRR.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// Conditions describe the state of the configuration on the node.
+	// The known condition types are Translated, Merged and Reloaded.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -252,6 +856,38 @@ func init() {
 	SchemeBuilder.Register(&FRRConfiguration{}, &FRRConfigurationList{})
 }
 
+// +kubebuilder:validation:Enum=internal;external
+type DynamicASNMode string
+
+const (
+	InternalASNMode DynamicASNMode = "internal"
+	ExternalASNMode DynamicASNMode = "external"
+)
+
+// GracefulRestartMode is the role played by a BGP speaker in the graceful
+// restart procedure: restart to also preserve the forwarding state across its own
+// restarts, helper to only retain the routes of the restarting peers, disabled
+// to turn graceful restart off.
+// +kubebuilder:validation:Enum=restart;helper;disabled
+type GracefulRestartMode string
+
+const (
+	GracefulRestartRestart  GracefulRestartMode = "restart"
+	GracefulRestartHelper   GracefulRestartMode = "helper"
+	GracefulRestartDisabled GracefulRestartMode = "disabled"
+)
+
+// RedistributeType is the kind of the routes of the node redistributed
+// into BGP.
+// +kubebuilder:validation:Enum=connected;kernel;static
+type RedistributeType string
+
+const (
+	RedistributeConnected RedistributeType = "connected"
+	RedistributeKernel    RedistributeType = "kernel"
+	RedistributeStatic    RedistributeType = "static"
+)
+
 // +kubebuilder:validation:Enum=all;filtered
 type AllowMode string
 
@@ -259,3 +895,15 @@ const (
 	AllowAll        AllowMode = "all"
 	AllowRestricted AllowMode = "filtered"
 )
+
+const (
+	// ConditionTranslated reports whether the configuration was translated
+	// to the internal FRR representation.
+	ConditionTranslated = "Translated"
+	// ConditionMerged reports whether the configuration was merged with the
+	// other configurations selecting the same node.
+	ConditionMerged = "Merged"
+	// ConditionReloaded reports whether FRR was reloaded with a configuration
+	// containing the current generation of the configuration.
+	ConditionReloaded = "Reloaded"
+)
@@ -0,0 +1,82 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// FRRNodeStateSpec defines the desired state of FRRNodeState.
+type FRRNodeStateSpec struct {
+}
+
+// FRRNodeStateStatus defines the observed state of FRRNodeState.
+type FRRNodeStateStatus struct {
+	// RenderedConfig is the FRR configuration file generated by the daemon
+	// from the FRRConfigurations selecting the node. Passwords are redacted.
+	// +optional
+	RenderedConfig string `json:"renderedConfig,omitempty"`
+
+	// LastError is the error that occurred while generating the configuration
+	// file or while signaling the reloader, if any.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+
+	// LastReloadResult is the result of the last reload of FRR, as reported by
+	// the reloader. It is either success or failure.
+	// +optional
+	LastReloadResult string `json:"lastReloadResult,omitempty"`
+
+	// LastReloadTime is the time of the last reload of FRR, as reported by
+	// the reloader.
+	// +optional
+	LastReloadTime *metav1.Time `json:"lastReloadTime,omitempty"`
+
+	// RunningConfig is the output of "show running-config" as collected by the
+	// reloader right after the last reload of FRR. Passwords are redacted.
+	// +optional
+	RunningConfig string `json:"runningConfig,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Reload",type=string,JSONPath=`.status.lastReloadResult`
+//+kubebuilder:printcolumn:name="Reload Time",type=date,JSONPath=`.status.lastReloadTime`
+
+// FRRNodeState exposes the status of FRR on a given node. It is named
+// after the node and it is maintained by the daemon running on it.
+type FRRNodeState struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FRRNodeStateSpec   `json:"spec,omitempty"`
+	Status FRRNodeStateStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// FRRNodeStateList contains a list of FRRNodeState.
+type FRRNodeStateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FRRNodeState `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&FRRNodeState{}, &FRRNodeStateList{})
+}
@@ -22,9 +22,46 @@ limitations under the License.
 package v1beta1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ASPathPrepend) DeepCopyInto(out *ASPathPrepend) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ASPathPrepend.
+func (in *ASPathPrepend) DeepCopy() *ASPathPrepend {
+	if in == nil {
+		return nil
+	}
+	out := new(ASPathPrepend)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ASPathPrependPrefixes) DeepCopyInto(out *ASPathPrependPrefixes) {
+	*out = *in
+	if in.Prefixes != nil {
+		in, out := &in.Prefixes, &out.Prefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.ASPathPrepend = in.ASPathPrepend
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ASPathPrependPrefixes.
+func (in *ASPathPrependPrefixes) DeepCopy() *ASPathPrependPrefixes {
+	if in == nil {
+		return nil
+	}
+	out := new(ASPathPrependPrefixes)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Advertise) DeepCopyInto(out *Advertise) {
 	*out = *in
@@ -43,6 +80,33 @@ func (in *Advertise) DeepCopyInto(out *Advertise) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	out.ASPathPrepend = in.ASPathPrepend
+	if in.PrefixesWithASPathPrepend != nil {
+		in, out := &in.PrefixesWithASPathPrepend, &out.PrefixesWithASPathPrepend
+		*out = make([]ASPathPrependPrefixes, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MED != nil {
+		in, out := &in.MED, &out.MED
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.PrefixesWithMED != nil {
+		in, out := &in.PrefixesWithMED, &out.PrefixesWithMED
+		*out = make([]MEDPrefixes, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PrefixesWithOrigin != nil {
+		in, out := &in.PrefixesWithOrigin, &out.PrefixesWithOrigin
+		*out = make([]OriginPrefixes, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Advertise.
@@ -55,6 +119,21 @@ func (in *Advertise) DeepCopy() *Advertise {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Aggregate) DeepCopyInto(out *Aggregate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Aggregate.
+func (in *Aggregate) DeepCopy() *Aggregate {
+	if in == nil {
+		return nil
+	}
+	out := new(Aggregate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AllowedPrefixes) DeepCopyInto(out *AllowedPrefixes) {
 	*out = *in
@@ -63,6 +142,11 @@ func (in *AllowedPrefixes) DeepCopyInto(out *AllowedPrefixes) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.PrefixSelectors != nil {
+		in, out := &in.PrefixSelectors, &out.PrefixSelectors
+		*out = make([]PrefixSelector, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedPrefixes.
@@ -105,6 +189,13 @@ func (in *BGPConfig) DeepCopyInto(out *BGPConfig) {
 		*out = make([]BFDProfile, len(*in))
 		copy(*out, *in)
 	}
+	if in.NeighborTemplates != nil {
+		in, out := &in.NeighborTemplates, &out.NeighborTemplates
+		*out = make([]NeighborTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BGPConfig.
@@ -117,6 +208,95 @@ func (in *BGPConfig) DeepCopy() *BGPConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BGPSessionState) DeepCopyInto(out *BGPSessionState) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BGPSessionState.
+func (in *BGPSessionState) DeepCopy() *BGPSessionState {
+	if in == nil {
+		return nil
+	}
+	out := new(BGPSessionState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BGPSessionState) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BGPSessionStateList) DeepCopyInto(out *BGPSessionStateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BGPSessionState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BGPSessionStateList.
+func (in *BGPSessionStateList) DeepCopy() *BGPSessionStateList {
+	if in == nil {
+		return nil
+	}
+	out := new(BGPSessionStateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BGPSessionStateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BGPSessionStateSpec) DeepCopyInto(out *BGPSessionStateSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BGPSessionStateSpec.
+func (in *BGPSessionStateSpec) DeepCopy() *BGPSessionStateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BGPSessionStateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BGPSessionStateStatus) DeepCopyInto(out *BGPSessionStateStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BGPSessionStateStatus.
+func (in *BGPSessionStateStatus) DeepCopy() *BGPSessionStateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BGPSessionStateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommunityPrefixes) DeepCopyInto(out *CommunityPrefixes) {
 	*out = *in
@@ -137,13 +317,70 @@ func (in *CommunityPrefixes) DeepCopy() *CommunityPrefixes {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DynamicNeighbor) DeepCopyInto(out *DynamicNeighbor) {
+	*out = *in
+	if in.ListenRanges != nil {
+		in, out := &in.ListenRanges, &out.ListenRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.PasswordSecret = in.PasswordSecret
+	out.HoldTime = in.HoldTime
+	out.KeepaliveTime = in.KeepaliveTime
+	in.ToAdvertise.DeepCopyInto(&out.ToAdvertise)
+	in.ToReceive.DeepCopyInto(&out.ToReceive)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DynamicNeighbor.
+func (in *DynamicNeighbor) DeepCopy() *DynamicNeighbor {
+	if in == nil {
+		return nil
+	}
+	out := new(DynamicNeighbor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EVPN) DeepCopyInto(out *EVPN) {
+	*out = *in
+	if in.VNIs != nil {
+		in, out := &in.VNIs, &out.VNIs
+		*out = make([]VNI, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ImportRTs != nil {
+		in, out := &in.ImportRTs, &out.ImportRTs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExportRTs != nil {
+		in, out := &in.ExportRTs, &out.ExportRTs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EVPN.
+func (in *EVPN) DeepCopy() *EVPN {
+	if in == nil {
+		return nil
+	}
+	out := new(EVPN)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FRRConfiguration) DeepCopyInto(out *FRRConfiguration) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FRRConfiguration.
@@ -200,6 +437,8 @@ func (in *FRRConfigurationList) DeepCopyObject() runtime.Object {
 func (in *FRRConfigurationSpec) DeepCopyInto(out *FRRConfigurationSpec) {
 	*out = *in
 	in.BGP.DeepCopyInto(&out.BGP)
+	in.NodeSelector.DeepCopyInto(&out.NodeSelector)
+	out.Raw = in.Raw
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FRRConfigurationSpec.
@@ -215,6 +454,13 @@ func (in *FRRConfigurationSpec) DeepCopy() *FRRConfigurationSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FRRConfigurationStatus) DeepCopyInto(out *FRRConfigurationStatus) {
 	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FRRConfigurationStatus.
@@ -227,6 +473,136 @@ func (in *FRRConfigurationStatus) DeepCopy() *FRRConfigurationStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FRRNodeState) DeepCopyInto(out *FRRNodeState) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FRRNodeState.
+func (in *FRRNodeState) DeepCopy() *FRRNodeState {
+	if in == nil {
+		return nil
+	}
+	out := new(FRRNodeState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FRRNodeState) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FRRNodeStateList) DeepCopyInto(out *FRRNodeStateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]FRRNodeState, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FRRNodeStateList.
+func (in *FRRNodeStateList) DeepCopy() *FRRNodeStateList {
+	if in == nil {
+		return nil
+	}
+	out := new(FRRNodeStateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *FRRNodeStateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FRRNodeStateSpec) DeepCopyInto(out *FRRNodeStateSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FRRNodeStateSpec.
+func (in *FRRNodeStateSpec) DeepCopy() *FRRNodeStateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FRRNodeStateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FRRNodeStateStatus) DeepCopyInto(out *FRRNodeStateStatus) {
+	*out = *in
+	if in.LastReloadTime != nil {
+		in, out := &in.LastReloadTime, &out.LastReloadTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FRRNodeStateStatus.
+func (in *FRRNodeStateStatus) DeepCopy() *FRRNodeStateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FRRNodeStateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GracefulRestart) DeepCopyInto(out *GracefulRestart) {
+	*out = *in
+	out.RestartTime = in.RestartTime
+	out.StalePathTime = in.StalePathTime
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GracefulRestart.
+func (in *GracefulRestart) DeepCopy() *GracefulRestart {
+	if in == nil {
+		return nil
+	}
+	out := new(GracefulRestart)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Import) DeepCopyInto(out *Import) {
+	*out = *in
+	if in.PrefixSelectors != nil {
+		in, out := &in.PrefixSelectors, &out.PrefixSelectors
+		*out = make([]PrefixSelector, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Import.
+func (in *Import) DeepCopy() *Import {
+	if in == nil {
+		return nil
+	}
+	out := new(Import)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalPrefPrefixes) DeepCopyInto(out *LocalPrefPrefixes) {
 	*out = *in
@@ -247,12 +623,74 @@ func (in *LocalPrefPrefixes) DeepCopy() *LocalPrefPrefixes {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MEDPrefixes) DeepCopyInto(out *MEDPrefixes) {
+	*out = *in
+	if in.Prefixes != nil {
+		in, out := &in.Prefixes, &out.Prefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MEDPrefixes.
+func (in *MEDPrefixes) DeepCopy() *MEDPrefixes {
+	if in == nil {
+		return nil
+	}
+	out := new(MEDPrefixes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaximumPrefix) DeepCopyInto(out *MaximumPrefix) {
+	*out = *in
+	out.RestartAfter = in.RestartAfter
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaximumPrefix.
+func (in *MaximumPrefix) DeepCopy() *MaximumPrefix {
+	if in == nil {
+		return nil
+	}
+	out := new(MaximumPrefix)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Multipath) DeepCopyInto(out *Multipath) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Multipath.
+func (in *Multipath) DeepCopy() *Multipath {
+	if in == nil {
+		return nil
+	}
+	out := new(Multipath)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Neighbor) DeepCopyInto(out *Neighbor) {
 	*out = *in
+	out.UpdateSource = in.UpdateSource
 	out.PasswordSecret = in.PasswordSecret
 	out.HoldTime = in.HoldTime
 	out.KeepaliveTime = in.KeepaliveTime
+	if in.EBGPMultiHop != nil {
+		in, out := &in.EBGPMultiHop, &out.EBGPMultiHop
+		*out = new(bool)
+		**out = **in
+	}
+	if in.MaximumPrefixes != nil {
+		in, out := &in.MaximumPrefixes, &out.MaximumPrefixes
+		*out = make([]MaximumPrefix, len(*in))
+		copy(*out, *in)
+	}
 	in.ToAdvertise.DeepCopyInto(&out.ToAdvertise)
 	in.ToReceive.DeepCopyInto(&out.ToReceive)
 }
@@ -267,6 +705,98 @@ func (in *Neighbor) DeepCopy() *Neighbor {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NeighborTemplate) DeepCopyInto(out *NeighborTemplate) {
+	*out = *in
+	out.PasswordSecret = in.PasswordSecret
+	out.HoldTime = in.HoldTime
+	out.KeepaliveTime = in.KeepaliveTime
+	in.ToAdvertise.DeepCopyInto(&out.ToAdvertise)
+	in.ToReceive.DeepCopyInto(&out.ToReceive)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NeighborTemplate.
+func (in *NeighborTemplate) DeepCopy() *NeighborTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(NeighborTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStatus.
+func (in *NodeStatus) DeepCopy() *NodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OriginPrefixes) DeepCopyInto(out *OriginPrefixes) {
+	*out = *in
+	if in.Prefixes != nil {
+		in, out := &in.Prefixes, &out.Prefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OriginPrefixes.
+func (in *OriginPrefixes) DeepCopy() *OriginPrefixes {
+	if in == nil {
+		return nil
+	}
+	out := new(OriginPrefixes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrefixSelector) DeepCopyInto(out *PrefixSelector) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrefixSelector.
+func (in *PrefixSelector) DeepCopy() *PrefixSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(PrefixSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RawConfig) DeepCopyInto(out *RawConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RawConfig.
+func (in *RawConfig) DeepCopy() *RawConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RawConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Receive) DeepCopyInto(out *Receive) {
 	*out = *in
@@ -283,6 +813,61 @@ func (in *Receive) DeepCopy() *Receive {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Redistribute) DeepCopyInto(out *Redistribute) {
+	*out = *in
+	if in.PrefixSelectors != nil {
+		in, out := &in.PrefixSelectors, &out.PrefixSelectors
+		*out = make([]PrefixSelector, len(*in))
+		copy(*out, *in)
+	}
+	if in.MED != nil {
+		in, out := &in.MED, &out.MED
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.Communities != nil {
+		in, out := &in.Communities, &out.Communities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Redistribute.
+func (in *Redistribute) DeepCopy() *Redistribute {
+	if in == nil {
+		return nil
+	}
+	out := new(Redistribute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteTargets) DeepCopyInto(out *RouteTargets) {
+	*out = *in
+	if in.Import != nil {
+		in, out := &in.Import, &out.Import
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteTargets.
+func (in *RouteTargets) DeepCopy() *RouteTargets {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteTargets)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Router) DeepCopyInto(out *Router) {
 	*out = *in
@@ -293,11 +878,41 @@ func (in *Router) DeepCopyInto(out *Router) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.DynamicNeighbors != nil {
+		in, out := &in.DynamicNeighbors, &out.DynamicNeighbors
+		*out = make([]DynamicNeighbor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Prefixes != nil {
 		in, out := &in.Prefixes, &out.Prefixes
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Aggregates != nil {
+		in, out := &in.Aggregates, &out.Aggregates
+		*out = make([]Aggregate, len(*in))
+		copy(*out, *in)
+	}
+	if in.Redistribute != nil {
+		in, out := &in.Redistribute, &out.Redistribute
+		*out = make([]Redistribute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	out.GracefulRestart = in.GracefulRestart
+	if in.Imports != nil {
+		in, out := &in.Imports, &out.Imports
+		*out = make([]Import, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.RouteTargets.DeepCopyInto(&out.RouteTargets)
+	in.EVPN.DeepCopyInto(&out.EVPN)
+	out.Multipath = in.Multipath
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Router.
@@ -309,3 +924,43 @@ func (in *Router) DeepCopy() *Router {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateSource) DeepCopyInto(out *UpdateSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateSource.
+func (in *UpdateSource) DeepCopy() *UpdateSource {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VNI) DeepCopyInto(out *VNI) {
+	*out = *in
+	if in.ImportRTs != nil {
+		in, out := &in.ImportRTs, &out.ImportRTs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ExportRTs != nil {
+		in, out := &in.ExportRTs, &out.ExportRTs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VNI.
+func (in *VNI) DeepCopy() *VNI {
+	if in == nil {
+		return nil
+	}
+	out := new(VNI)
+	in.DeepCopyInto(out)
+	return out
+}
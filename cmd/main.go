@@ -17,18 +17,24 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
@@ -44,6 +50,10 @@ var (
 	setupLog = ctrl.Log.WithName("setup")
 )
 
+// managerShutdownTimeout is the maximum time the manager takes to stop on
+// termination, before the graceful shutdown starts.
+const managerShutdownTimeout = 5 * time.Second
+
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
@@ -53,16 +63,24 @@ func init() {
 
 func main() {
 	var (
-		metricsAddr string
-		probeAddr   string
-		logLevel    string
-		nodeName    string // TODO not using this now, but we'll need it when we implement the node selector
+		metricsAddr             string
+		probeAddr               string
+		logLevel                string
+		nodeName                string
+		namespace               string
+		gracefulShutdownTimeout time.Duration
+		terminationGracePeriod  time.Duration
 	)
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.StringVar(&logLevel, "log-level", "info", fmt.Sprintf("log level. must be one of: [%s]", logging.Levels.String()))
 	flag.StringVar(&nodeName, "node-name", "", "The node this daemon is running on.")
+	flag.StringVar(&namespace, "namespace", "", "The namespace this daemon is deployed in.")
+	flag.DurationVar(&gracefulShutdownTimeout, "graceful-shutdown-timeout", 0,
+		"When set, on termination the BGP graceful shutdown is enabled and the daemon waits the given time before exiting, letting the peers divert the traffic away from the node.")
+	flag.DurationVar(&terminationGracePeriod, "termination-grace-period", 30*time.Second,
+		"The termination grace period of the pod, which must be enough for the graceful shutdown to complete. It must match the terminationGracePeriodSeconds of the pod.")
 
 	opts := zap.Options{
 		Development: true,
@@ -76,11 +94,35 @@ func main() {
 		os.Exit(1)
 	}
 
+	// On termination the manager is stopped, then FRR is reloaded with the
+	// graceful shutdown enabled and the daemon waits for the given timeout:
+	// all of them must complete before the pod is killed.
+	if gracefulShutdownTimeout > 0 {
+		required := managerShutdownTimeout + frr.GracefulShutdownReloadTimeout + gracefulShutdownTimeout
+		if required >= terminationGracePeriod {
+			fmt.Printf("invalid graceful shutdown timeout: the graceful shutdown may take up to %s, which must be lower than the termination grace period %s\n",
+				required, terminationGracePeriod)
+			os.Exit(1)
+		}
+	}
+
+	shutdownTimeout := managerShutdownTimeout
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		MetricsBindAddress:     metricsAddr,
-		Port:                   9443,
-		HealthProbeBindAddress: probeAddr,
+		Scheme:                  scheme,
+		MetricsBindAddress:      metricsAddr,
+		Port:                    9443,
+		HealthProbeBindAddress:  probeAddr,
+		GracefulShutdownTimeout: &shutdownTimeout,
+		NewCache: cache.BuilderWithOptions(cache.Options{
+			SelectorsByObject: cache.SelectorsByObject{
+				&corev1.Node{}: {
+					Field: fields.OneTermEqualSelector("metadata.name", nodeName),
+				},
+				&corev1.Secret{}: {
+					Field: fields.OneTermEqualSelector("metadata.namespace", namespace),
+				},
+			},
+		}),
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -88,15 +130,45 @@ func main() {
 	}
 
 	ctx := ctrl.SetupSignalHandler()
+
+	reloadStatusChan := make(chan event.GenericEvent, 1)
+	nodeStateChan := make(chan event.GenericEvent, 1)
+	reloadStatusNotifier := func() {
+		for _, c := range []chan event.GenericEvent{reloadStatusChan, nodeStateChan} {
+			select {
+			case c <- controller.NewReloadStatusEvent():
+			default:
+			}
+		}
+	}
+	// FRR is stopped only after the manager, so that it can still be reloaded
+	// with the graceful shutdown enabled.
+	frrCtx, stopFRR := context.WithCancel(context.Background())
+	frrHandler := frr.NewFRR(frrCtx, reloadStatusNotifier, logger, logging.Level(logLevel))
+
 	if err = (&controller.FRRConfigurationReconciler{
-		Client:     mgr.GetClient(),
-		Scheme:     mgr.GetScheme(),
-		FRRHandler: frr.NewFRR(ctx, logger, logging.Level(logLevel)),
-		Logger:     logger,
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		FRRHandler:      frrHandler,
+		Logger:          logger,
+		NodeName:        nodeName,
+		DaemonNamespace: namespace,
+		ReloadStatus:    reloadStatusChan,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "FRRConfiguration")
 		os.Exit(1)
 	}
+	if err = (&controller.FRRNodeStateReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		FRRStatus:    frrHandler,
+		Logger:       logger,
+		NodeName:     nodeName,
+		ReloadStatus: nodeStateChan,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "FRRNodeState")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -109,7 +181,14 @@ func main() {
 	}
 
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctx); err != nil {
+	err = mgr.Start(ctx)
+	stopFRR()
+	if gracefulShutdownTimeout > 0 {
+		if err := frrHandler.GracefulShutdown(gracefulShutdownTimeout); err != nil {
+			setupLog.Error(err, "failed to enable the graceful shutdown")
+		}
+	}
+	if err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
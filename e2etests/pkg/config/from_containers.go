@@ -24,7 +24,7 @@ func NeighborsForContainers(frrs []*frrcontainer.FRR, modify ...func(frr.Neighbo
 				ASN:          f.RouterConfig.ASN,
 				Address:      address,
 				Port:         f.RouterConfig.BGPPort,
-				EBGPMultiHop: ebgpMultihop,
+				EBGPMultiHop: &ebgpMultihop,
 			}
 			res = append(res, neigh)
 		}
@@ -111,7 +111,7 @@ func (c *bfd) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *bfd) Collect(ch chan<- prometheus.Metric) {
-	peers, err := getBFDPeers(c.frrCli)
+	peers, err := vtysh.BFDPeers(c.frrCli)
 	if err != nil {
 		level.Error(c.Log).Log("error", err, "msg", "failed to fetch BFD peers from FRR")
 		return
@@ -155,26 +155,6 @@ func updatePeersCountersMetrics(ch chan<- prometheus.Metric, peersCountersPerVRF
 	}
 }
 
-func getBFDPeers(frrCli vtysh.Cli) (map[string][]frr.BFDPeer, error) {
-	vrfs, err := vtysh.VRFs(frrCli)
-	if err != nil {
-		return nil, err
-	}
-	res := make(map[string][]frr.BFDPeer)
-	for _, vrf := range vrfs {
-		peersJSON, err := frrCli(fmt.Sprintf("show bfd vrf %s peers json", vrf))
-		if err != nil {
-			return nil, err
-		}
-		peers, err := frr.ParseBFDPeers(peersJSON)
-		if err != nil {
-			return nil, err
-		}
-		res[vrf] = peers
-	}
-	return res, nil
-}
-
 func getBFDPeersCounters(frrCli vtysh.Cli) (map[string][]bfdPeerCounters, error) {
 	vrfs, err := vtysh.VRFs(frrCli)
 	if err != nil {
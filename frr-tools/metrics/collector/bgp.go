@@ -15,6 +15,8 @@ import (
 
 var labels = []string{"peer", "vrf"}
 
+var familyLabels = []string{"peer", "vrf", "family"}
+
 var (
 	sessionUpDesc = prometheus.NewDesc(
 		prometheus.BuildFQName(Namespace, Subsystem, SessionUp.Name),
@@ -99,6 +101,20 @@ var (
 		labels,
 		nil,
 	)
+
+	receivedPrefixesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, Subsystem, "received_prefixes"),
+		"Number of prefixes currently accepted from the BGP session, per address family",
+		familyLabels,
+		nil,
+	)
+
+	maxPrefixesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, Subsystem, "max_prefixes"),
+		"Maximum number of prefixes accepted from the BGP session, per address family",
+		familyLabels,
+		nil,
+	)
 )
 
 type bgp struct {
@@ -129,10 +145,12 @@ func (c *bgp) Describe(ch chan<- *prometheus.Desc) {
 	ch <- routeRefreshSentedDesc
 	ch <- totalSentDesc
 	ch <- totalReceivedDesc
+	ch <- receivedPrefixesDesc
+	ch <- maxPrefixesDesc
 }
 
 func (c *bgp) Collect(ch chan<- prometheus.Metric) {
-	neighbors, err := getBGPNeighbors(c.frrCli)
+	neighbors, err := vtysh.BGPNeighbors(c.frrCli)
 	if err != nil {
 		level.Error(c.Log).Log("error", err, "msg", "failed to fetch BGP neighbors from FRR")
 		return
@@ -148,7 +166,7 @@ func updateNeighborsMetrics(ch chan<- prometheus.Metric, neighbors map[string][]
 			if !n.Connected {
 				sessionUp = 0
 			}
-			peerLabel := fmt.Sprintf("%s:%d", n.IP.String(), n.Port)
+			peerLabel := fmt.Sprintf("%s:%d", n.Peer(), n.Port)
 
 			ch <- prometheus.MustNewConstMetric(sessionUpDesc, prometheus.GaugeValue, float64(sessionUp), peerLabel, vrf)
 			ch <- prometheus.MustNewConstMetric(prefixesDesc, prometheus.GaugeValue, float64(n.PrefixSent), peerLabel, vrf)
@@ -162,27 +180,15 @@ func updateNeighborsMetrics(ch chan<- prometheus.Metric, neighbors map[string][]
 			ch <- prometheus.MustNewConstMetric(routeRefreshSentedDesc, prometheus.CounterValue, float64(n.MsgStats.RouteRefreshSent), peerLabel, vrf)
 			ch <- prometheus.MustNewConstMetric(totalSentDesc, prometheus.CounterValue, float64(n.MsgStats.TotalSent), peerLabel, vrf)
 			ch <- prometheus.MustNewConstMetric(totalReceivedDesc, prometheus.CounterValue, float64(n.MsgStats.TotalReceived), peerLabel, vrf)
-		}
-	}
-}
 
-func getBGPNeighbors(frrCli vtysh.Cli) (map[string][]*frr.Neighbor, error) {
-	vrfs, err := vtysh.VRFs(frrCli)
-	if err != nil {
-		return nil, err
-	}
-	neighbors := make(map[string][]*frr.Neighbor, 0)
-	for _, vrf := range vrfs {
-		res, err := frrCli(fmt.Sprintf("show bgp vrf %s neighbors json", vrf))
-		if err != nil {
-			return nil, err
-		}
-
-		neighborsPerVRF, err := frr.ParseNeighbours(res)
-		if err != nil {
-			return nil, err
+			for family, c := range n.PrefixCounters {
+				ch <- prometheus.MustNewConstMetric(receivedPrefixesDesc, prometheus.GaugeValue, float64(c.Received), peerLabel, vrf, family)
+				// The limit is exported only when configured, so that it can be
+				// compared with the received prefixes to alert before it trips.
+				if c.MaxPrefixes > 0 {
+					ch <- prometheus.MustNewConstMetric(maxPrefixesDesc, prometheus.GaugeValue, float64(c.MaxPrefixes), peerLabel, vrf, family)
+				}
+			}
 		}
-		neighbors[vrf] = neighborsPerVRF
 	}
-	return neighbors, nil
 }
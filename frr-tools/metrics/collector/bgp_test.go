@@ -22,6 +22,13 @@ var (
 	# HELP frrk8s_bgp_keepalives_sent Number of BGP keepalive messages sent
 	# TYPE frrk8s_bgp_keepalives_sent counter
 	frrk8s_bgp_keepalives_sent{peer="{{ .NeighborIP }}", vrf="{{ .NeighborVRF }}"} {{ .KeepalivesSent }}
+	{{- if .MaxPrefixes }}
+	# HELP frrk8s_bgp_max_prefixes Maximum number of prefixes accepted from the BGP session, per address family
+	# TYPE frrk8s_bgp_max_prefixes gauge
+	{{- end }}
+	{{- range $family, $max := .MaxPrefixes }}
+	frrk8s_bgp_max_prefixes{family="{{ $family }}", peer="{{ $.NeighborIP }}", vrf="{{ $.NeighborVRF }}"} {{ $max }}
+	{{- end }}
 	# HELP frrk8s_bgp_notifications_sent Number of BGP notification messages sent
 	# TYPE frrk8s_bgp_notifications_sent counter
 	frrk8s_bgp_notifications_sent{peer="{{ .NeighborIP }}", vrf="{{ .NeighborVRF }}"} {{ .NotificationsSent }}
@@ -31,6 +38,11 @@ var (
 	# HELP frrk8s_bgp_opens_sent Number of BGP open messages sent
 	# TYPE frrk8s_bgp_opens_sent counter
 	frrk8s_bgp_opens_sent{peer="{{ .NeighborIP }}", vrf="{{ .NeighborVRF }}"} {{ .OpensSent }}
+	# HELP frrk8s_bgp_received_prefixes Number of prefixes currently accepted from the BGP session, per address family
+	# TYPE frrk8s_bgp_received_prefixes gauge
+	{{- range $family, $received := .ReceivedPrefixes }}
+	frrk8s_bgp_received_prefixes{family="{{ $family }}", peer="{{ $.NeighborIP }}", vrf="{{ $.NeighborVRF }}"} {{ $received }}
+	{{- end }}
 	# HELP frrk8s_bgp_route_refresh_sent Number of BGP route refresh messages sent
 	# TYPE frrk8s_bgp_route_refresh_sent counter
 	frrk8s_bgp_route_refresh_sent{peer="{{ .NeighborIP }}", vrf="{{ .NeighborVRF }}"} {{ .RouteRefreshSent }}
@@ -68,6 +80,8 @@ var (
 		notificationsSent    int
 		totalSent            int
 		totalReceived        int
+		receivedPrefixes     map[string]int
+		maxPrefixes          map[string]int
 	}{
 		{
 			desc:                 "Output contains only IPv4 advertisements",
@@ -86,6 +100,7 @@ var (
 			notificationsSent:    2,
 			totalSent:            15,
 			totalReceived:        15,
+			receivedPrefixes:     map[string]int{"ipv4Unicast": 0},
 		},
 		{
 			desc:                 "Output contains mixed IPv4 and IPv6 advertisements",
@@ -104,6 +119,27 @@ var (
 			notificationsSent:    2,
 			totalSent:            15,
 			totalReceived:        15,
+			receivedPrefixes:     map[string]int{"ipv4Unicast": 0, "ipv6Unicast": 13},
+		},
+		{
+			desc:                 "Output contains a maximum prefix limit",
+			vtyshOutput:          neighborsMaxPrefixes,
+			neighborIP:           "172.18.0.4:181",
+			neighborVRF:          "default",
+			announcedPrefixes:    6,
+			sessionUp:            1,
+			updatesTotal:         3,
+			updatesTotalReceived: 3,
+			keepalivesSent:       4,
+			keepalivesReceived:   4,
+			opensSent:            1,
+			opensReceived:        1,
+			routeRefreshSent:     5,
+			notificationsSent:    2,
+			totalSent:            15,
+			totalReceived:        15,
+			receivedPrefixes:     map[string]int{"ipv4Unicast": 0, "ipv6Unicast": 13},
+			maxPrefixes:          map[string]int{"ipv6Unicast": 20},
 		},
 	}
 	neighborsIPv4Only = `
@@ -370,6 +406,155 @@ var (
 		}
 	  }	  
 	`
+	neighborsMaxPrefixes = `
+	{
+		"172.18.0.4":{
+		  "remoteAs":64512,
+		  "localAs":64513,
+		  "nbrExternalLink":true,
+		  "hostname":"bgpd",
+		  "bgpVersion":4,
+		  "remoteRouterId":"172.18.0.4",
+		  "localRouterId":"172.18.0.3",
+		  "bgpState":"Established",
+		  "bgpTimerUpMsec":1082000,
+		  "bgpTimerUpString":"00:18:02",
+		  "bgpTimerUpEstablishedEpoch":1632032518,
+		  "bgpTimerLastRead":2000,
+		  "bgpTimerLastWrite":2000,
+		  "bgpInUpdateElapsedTimeMsecs":1081000,
+		  "bgpTimerHoldTimeMsecs":180000,
+		  "bgpTimerKeepAliveIntervalMsecs":60000,
+		  "neighborCapabilities":{
+			"4byteAs":"advertisedAndReceived",
+			"addPath":{
+			  "ipv4Unicast":{
+				"rxAdvertisedAndReceived":true
+			  },
+			  "ipv6Unicast":{
+				"rxAdvertisedAndReceived":true
+			  }
+			},
+			"routeRefresh":"advertisedAndReceivedOldNew",
+			"multiprotocolExtensions":{
+			  "ipv4Unicast":{
+				"advertisedAndReceived":true
+			  },
+			  "ipv6Unicast":{
+				"advertisedAndReceived":true
+			  }
+			},
+			"hostName":{
+			  "advHostName":"kind-control-plane",
+			  "advDomainName":"n\/a",
+			  "rcvHostName":"bgpd",
+			  "rcvDomainName":"n\/a"
+			},
+			"gracefulRestart":"advertisedAndReceived",
+			"gracefulRestartRemoteTimerMsecs":120000,
+			"addressFamiliesByPeer":"none"
+		  },
+		  "gracefulRestartInfo":{
+			"endOfRibSend":{
+			  "ipv4Unicast":true,
+			  "ipv6Unicast":true
+			},
+			"endOfRibRecv":{
+			  "ipv4Unicast":true,
+			  "ipv6Unicast":true
+			},
+			"localGrMode":"Helper*",
+			"remoteGrMode":"Helper",
+			"rBit":true,
+			"timers":{
+			  "configuredRestartTimer":120,
+			  "receivedRestartTimer":120
+			},
+			"ipv4Unicast":{
+			  "fBit":false,
+			  "endOfRibStatus":{
+				"endOfRibSend":true,
+				"endOfRibSentAfterUpdate":true,
+				"endOfRibRecv":true
+			  },
+			  "timers":{
+				"stalePathTimer":360
+			  }
+			},
+			"ipv6Unicast":{
+			  "fBit":false,
+			  "endOfRibStatus":{
+				"endOfRibSend":true,
+				"endOfRibSentAfterUpdate":true,
+				"endOfRibRecv":true
+			  },
+			  "timers":{
+				"stalePathTimer":360
+			  }
+			}
+		  },
+		  "messageStats":{
+			"depthInq":0,
+			"depthOutq":0,
+			"opensSent":1,
+			"opensRecv":1,
+			"notificationsSent":2,
+			"notificationsRecv":2,
+			"updatesSent":3,
+			"updatesRecv":3,
+			"keepalivesSent":4,
+			"keepalivesRecv":4,
+			"routeRefreshSent":5,
+			"routeRefreshRecv":5,
+			"capabilitySent":0,
+			"capabilityRecv":0,
+			"totalSent":15,
+			"totalRecv":15
+		  },
+		  "minBtwnAdvertisementRunsTimerMsecs":0,
+		  "addressFamilyInfo":{
+			"ipv4Unicast":{
+			  "updateGroupId":1,
+			  "subGroupId":1,
+			  "packetQueueLength":0,
+			  "commAttriSentToNbr":"extendedAndStandard",
+			  "acceptedPrefixCounter":0,
+			  "sentPrefixCounter":3
+			},
+			"ipv6Unicast":{
+			  "peerGroupMember":"uplink",
+			  "updateGroupId":2,
+			  "subGroupId":2,
+			  "packetQueueLength":0,
+			  "commAttriSentToNbr":"extendedAndStandard",
+			  "outboundPathPolicyConfig":true,
+			  "outgoingUpdatePrefixFilterList":"only-host-prefixes",
+			  "acceptedPrefixCounter":13,
+			  "prefixAllowedMax":20,
+			  "prefixAllowedWarningThresh":75,
+			  "sentPrefixCounter":3
+			}
+		  },
+		  "connectionsEstablished":1,
+		  "connectionsDropped":0,
+		  "lastResetTimerMsecs":1083000,
+		  "lastResetDueTo":"Waiting for peer OPEN",
+		  "lastResetCode":32,
+		  "hostLocal":"172.18.0.3",
+		  "portLocal":42692,
+		  "hostForeign":"172.18.0.4",
+		  "portForeign":181,
+		  "nexthop":"172.18.0.3",
+		  "nexthopGlobal":"fc00:f853:ccd:e793::3",
+		  "nexthopLocal":"fe80::42:acff:fe12:3",
+		  "bgpConnection":"sharedNetwork",
+		  "connectRetryTimer":120,
+		  "estimatedRttInMsecs":2,
+		  "readThread":"on",
+		  "writeThread":"on"
+		}
+	  }	  
+	`
 	vrfVtysh = `{
 		"default":{
 		 "vrfId": 0,
@@ -459,6 +644,8 @@ func TestCollect(t *testing.T) {
 				"RouteRefreshSent":     tc.routeRefreshSent,
 				"TotalReceived":        tc.totalReceived,
 				"TotalSent":            tc.totalSent,
+				"ReceivedPrefixes":     tc.receivedPrefixes,
+				"MaxPrefixes":          tc.maxPrefixes,
 			})
 
 			if err != nil {
@@ -3,6 +3,7 @@
 package vtysh
 
 import (
+	"fmt"
 	"os/exec"
 
 	"github.com/metallb/frrk8s/internal/frr"
@@ -28,3 +29,46 @@ func VRFs(frrCli Cli) ([]string, error) {
 	}
 	return parsedVRFs, nil
 }
+
+// BGPNeighbors returns the BGP neighbors of all the VRFs, grouped by VRF.
+func BGPNeighbors(frrCli Cli) (map[string][]*frr.Neighbor, error) {
+	vrfs, err := VRFs(frrCli)
+	if err != nil {
+		return nil, err
+	}
+	neighbors := make(map[string][]*frr.Neighbor, 0)
+	for _, vrf := range vrfs {
+		res, err := frrCli(fmt.Sprintf("show bgp vrf %s neighbors json", vrf))
+		if err != nil {
+			return nil, err
+		}
+
+		neighborsPerVRF, err := frr.ParseNeighbours(res)
+		if err != nil {
+			return nil, err
+		}
+		neighbors[vrf] = neighborsPerVRF
+	}
+	return neighbors, nil
+}
+
+// BFDPeers returns the BFD peers of all the VRFs, grouped by VRF.
+func BFDPeers(frrCli Cli) (map[string][]frr.BFDPeer, error) {
+	vrfs, err := VRFs(frrCli)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]frr.BFDPeer)
+	for _, vrf := range vrfs {
+		peersJSON, err := frrCli(fmt.Sprintf("show bfd vrf %s peers json", vrf))
+		if err != nil {
+			return nil, err
+		}
+		peers, err := frr.ParseBFDPeers(peersJSON)
+		if err != nil {
+			return nil, err
+		}
+		res[vrf] = peers
+	}
+	return res, nil
+}
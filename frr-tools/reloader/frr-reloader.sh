@@ -19,6 +19,7 @@ reload_frr() {
   echo "Checking the configuration file syntax"
   if ! python3 /usr/lib/frr/frr-reload.py --test --stdout "$FILE_TO_RELOAD" 2>&1 | sed 's/password.*/password <retracted>/g'; then
     echo "Syntax error spotted: aborting.. $SECONDS seconds"
+    dump_running_config
     echo -n "$(date +%s) failure"  > "$STATUSFILE"
     return
   fi
@@ -26,14 +27,24 @@ reload_frr() {
   echo "Applying the configuration file"
   if ! python3 /usr/lib/frr/frr-reload.py --reload --overwrite --stdout "$FILE_TO_RELOAD" 2>&1 | sed 's/password.*/password <retracted>/g'; then
     echo "Failed to fully apply configuration file $SECONDS seconds"
+    dump_running_config
     echo -n "$(date +%s) failure"  > "$STATUSFILE"
     return
   fi
   
   echo "FRR reloaded successfully! $SECONDS seconds"
+  dump_running_config
   echo -n "$(date +%s) success"  > "$STATUSFILE"
 } 200<"$LOCKFILE"
 
+# The running config is dumped before writing the status file, so that
+# it is up to date when the status file is read.
+dump_running_config() {
+  if vtysh -c "show running-config" > "$RUNNINGCONFIGFILE.tmp" 2>/dev/null; then
+    mv -f "$RUNNINGCONFIGFILE.tmp" "$RUNNINGCONFIGFILE"
+  fi
+}
+
 kill_sleep() {
   kill "$sleep_pid"
 }
@@ -53,6 +64,7 @@ PIDFILE="$SHARED_VOLUME/reloader.pid"
 FILE_TO_RELOAD="$SHARED_VOLUME/frr.conf"
 LOCKFILE="$SHARED_VOLUME/lock"
 STATUSFILE="$SHARED_VOLUME/.status"
+RUNNINGCONFIGFILE="$SHARED_VOLUME/.running-config"
 
 clean_files
 echo "PID is: $$, writing to $PIDFILE"
@@ -0,0 +1,103 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"github.com/go-kit/log/level"
+	frrk8sv1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/frr-tools/metrics/vtysh"
+	"github.com/metallb/frrk8s/internal/controller"
+	"github.com/metallb/frrk8s/internal/frr"
+	"github.com/metallb/frrk8s/internal/logging"
+	"github.com/metallb/frrk8s/internal/version"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(frrk8sv1beta1.AddToScheme(scheme))
+}
+
+// The status publisher runs in a container where vtysh is available and
+// exposes the state of the BGP sessions of the node as BGPSessionStates.
+func main() {
+	var (
+		logLevel     string
+		nodeName     string
+		namespace    string
+		resyncPeriod time.Duration
+	)
+
+	flag.StringVar(&logLevel, "log-level", "info", fmt.Sprintf("log level. must be one of: [%s]", logging.Levels.String()))
+	flag.StringVar(&nodeName, "node-name", "", "The node this daemon is running on.")
+	flag.StringVar(&namespace, "namespace", "", "The namespace the BGPSessionStates are published in.")
+	flag.DurationVar(&resyncPeriod, "resync-period", time.Minute, "The interval between two collections of the state of the sessions.")
+	flag.Parse()
+
+	logger, err := logging.Init(logLevel)
+	if err != nil {
+		fmt.Printf("failed to initialize logging: %s\n", err)
+		os.Exit(1)
+	}
+
+	level.Info(logger).Log("version", version.Version(), "commit", version.CommitHash(), "branch", version.Branch(), "goversion", version.GoString(), "msg", "FRR status publisher starting "+version.String())
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+		Scheme: scheme,
+		// The daemon runs on the host network, so the endpoints are disabled
+		// to avoid clashing with the ones of the controller.
+		MetricsBindAddress:     "0",
+		HealthProbeBindAddress: "0",
+		NewCache: cache.BuilderWithOptions(cache.Options{
+			SelectorsByObject: cache.SelectorsByObject{
+				&corev1.Node{}: {
+					Field: fields.OneTermEqualSelector("metadata.name", nodeName),
+				},
+				&frrk8sv1beta1.BGPSessionState{}: {
+					Field: fields.OneTermEqualSelector("metadata.namespace", namespace),
+				},
+			},
+		}),
+	})
+	if err != nil {
+		level.Error(logger).Log("op", "startup", "error", err, "msg", "unable to start manager")
+		os.Exit(1)
+	}
+
+	if err = (&controller.BGPSessionStateReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Logger:    logger,
+		NodeName:  nodeName,
+		Namespace: namespace,
+		BGPNeighbors: func() (map[string][]*frr.Neighbor, error) {
+			return vtysh.BGPNeighbors(vtysh.Run)
+		},
+		BFDPeers: func() (map[string][]frr.BFDPeer, error) {
+			return vtysh.BFDPeers(vtysh.Run)
+		},
+		ResyncPeriod: resyncPeriod,
+	}).SetupWithManager(mgr); err != nil {
+		level.Error(logger).Log("op", "startup", "error", err, "msg", "unable to create controller", "controller", "BGPSessionState")
+		os.Exit(1)
+	}
+
+	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+		level.Error(logger).Log("op", "run", "error", err, "msg", "problem running manager")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"net"
+	"sort"
+
+	v1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/internal/frr"
+	"github.com/metallb/frrk8s/internal/ipfamily"
+)
+
+// aggregatesToFRR validates the aggregates of the given router, each one
+// required to cover at least one of the prefixes of the router, and
+// converts them splitting them per ip family.
+func aggregatesToFRR(r v1beta1.Router) ([]frr.AggregateConfig, []frr.AggregateConfig, error) {
+	var v4, v6 []frr.AggregateConfig
+	aggregates := map[string]frr.AggregateConfig{}
+	for _, a := range r.Aggregates {
+		_, cidr, err := net.ParseCIDR(a.Prefix)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid aggregate %s: %w", a.Prefix, err)
+		}
+		prefix := cidr.String()
+		if _, ok := aggregates[prefix]; ok {
+			return nil, nil, fmt.Errorf("duplicate aggregate %s", prefix)
+		}
+		if !coversAny(cidr, r.Prefixes) {
+			return nil, nil, fmt.Errorf("aggregate %s does not cover any of the prefixes of the router", prefix)
+		}
+		aggregates[prefix] = frr.AggregateConfig{
+			Prefix:      prefix,
+			SummaryOnly: a.SummaryOnly,
+			ASSet:       a.ASSet,
+		}
+	}
+
+	for _, a := range sortedAggregates(aggregates) {
+		if ipfamily.ForCIDRString(a.Prefix) == ipfamily.IPv6 {
+			v6 = append(v6, a)
+			continue
+		}
+		v4 = append(v4, a)
+	}
+	return v4, v6, nil
+}
+
+// coversAny tells if any of the given prefixes is more specific than
+// the aggregate and contained in it.
+func coversAny(aggregate *net.IPNet, prefixes []string) bool {
+	aggregateOnes, aggregateBits := aggregate.Mask.Size()
+	for _, p := range prefixes {
+		_, cidr, err := net.ParseCIDR(p)
+		if err != nil {
+			continue
+		}
+		ones, bits := cidr.Mask.Size()
+		if bits == aggregateBits && ones > aggregateOnes && aggregate.Contains(cidr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// aggregatePrefixes returns the prefixes of the given aggregates.
+func aggregatePrefixes(aggregates []frr.AggregateConfig) []string {
+	res := make([]string, 0, len(aggregates))
+	for _, a := range aggregates {
+		res = append(res, a.Prefix)
+	}
+	return res
+}
+
+// sortedAggregates returns the given aggregates sorted by prefix.
+func sortedAggregates(aggregates map[string]frr.AggregateConfig) []frr.AggregateConfig {
+	res := make([]frr.AggregateConfig, 0, len(aggregates))
+	for _, a := range aggregates {
+		res = append(res, a)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Prefix < res[j].Prefix
+	})
+	return res
+}
+
+// mergeAggregates unions the aggregates of two routers, failing if the same
+// aggregate is specified with different options.
+func mergeAggregates(curr, toMerge []frr.AggregateConfig) ([]frr.AggregateConfig, error) {
+	aggregates := map[string]frr.AggregateConfig{}
+	for _, a := range curr {
+		aggregates[a.Prefix] = a
+	}
+	for _, a := range toMerge {
+		existing, ok := aggregates[a.Prefix]
+		if ok && existing != a {
+			return nil, fmt.Errorf("different options for aggregate %s", a.Prefix)
+		}
+		aggregates[a.Prefix] = a
+	}
+	if len(aggregates) == 0 {
+		return nil, nil
+	}
+	return sortedAggregates(aggregates), nil
+}
@@ -4,29 +4,162 @@ package controller
 
 import (
 	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	v1beta1 "github.com/metallb/frrk8s/api/v1beta1"
 	"github.com/metallb/frrk8s/internal/frr"
 	"github.com/metallb/frrk8s/internal/ipfamily"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
-func apiToFRR(fromK8s v1beta1.FRRConfiguration) (*frr.Config, error) {
+// clusterResources holds the FRRConfigurations selecting the node, along
+// with the other resources needed to translate them.
+type clusterResources struct {
+	FRRConfigs []v1beta1.FRRConfiguration
+	// PasswordSecrets contains the secrets in the namespace of the daemon,
+	// indexed by name.
+	PasswordSecrets map[string]v1.Secret
+	// NodeAnnotations are the annotations of the node the daemon is running on.
+	NodeAnnotations map[string]string
+}
+
+// configError is returned when a given FRRConfiguration can't be
+// translated or merged with the others.
+type configError struct {
+	// index is the position of the configuration in the list passed to apiToFRR.
+	index int
+	// condition is the condition type the error refers to.
+	condition string
+	err       error
+}
+
+func (e *configError) Error() string {
+	return e.err.Error()
+}
+
+func (e *configError) Unwrap() error {
+	return e.err
+}
+
+// apiToFRR translates the given FRRConfigurations and merges them
+// in a single FRR configuration. The configurations are processed in
+// order, and the first one that fails is reported via a configError.
+func apiToFRR(resources clusterResources) (*frr.Config, error) {
 	res := &frr.Config{
 		Routers: make([]*frr.RouterConfig, 0),
-		//BFDProfiles: sm.bfdProfiles,
-		//ExtraConfig: sm.extraConfig,
+	}
+
+	for i, cfg := range resources.FRRConfigs {
+		frrConfig, err := configToFRR(cfg, resources)
+		if err != nil {
+			return nil, &configError{
+				index:     i,
+				condition: v1beta1.ConditionTranslated,
+				err:       fmt.Errorf("failed to translate configuration %s/%s: %w", cfg.Namespace, cfg.Name, err),
+			}
+		}
+		res, err = mergeConfigs(res, frrConfig)
+		if err != nil {
+			return nil, &configError{
+				index:     i,
+				condition: v1beta1.ConditionMerged,
+				err:       fmt.Errorf("failed to merge configuration %s/%s: %w", cfg.Namespace, cfg.Name, err),
+			}
+		}
+	}
+	res.ExtraConfig = rawConfigToFRR(resources.FRRConfigs)
+	return res, nil
+}
+
+// rawConfigToFRR concatenates the raw configuration snippets of the given
+// FRRConfigurations, ordered by priority and then by namespace and name
+// so that the result does not depend on the order they were listed in.
+func rawConfigToFRR(fromK8s []v1beta1.FRRConfiguration) string {
+	sorted := make([]v1beta1.FRRConfiguration, len(fromK8s))
+	copy(sorted, fromK8s)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Spec.Raw.Priority != sorted[j].Spec.Raw.Priority {
+			return sorted[i].Spec.Raw.Priority < sorted[j].Spec.Raw.Priority
+		}
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	snippets := make([]string, 0)
+	for _, cfg := range sorted {
+		raw := strings.TrimRight(cfg.Spec.Raw.Config, "\n")
+		if raw == "" {
+			continue
+		}
+		snippets = append(snippets, raw)
+	}
+	return strings.Join(snippets, "\n")
+}
+
+func configToFRR(fromK8s v1beta1.FRRConfiguration, resources clusterResources) (*frr.Config, error) {
+	res := &frr.Config{
+		Routers:     make([]*frr.RouterConfig, 0),
+		BFDProfiles: make([]frr.BFDProfile, 0),
+	}
+
+	profiles := sets.New[string]()
+	for _, p := range fromK8s.Spec.BGP.BFDProfiles {
+		if profiles.Has(p.Name) {
+			return nil, fmt.Errorf("duplicate bfd profile %s", p.Name)
+		}
+		profiles.Insert(p.Name)
+		res.BFDProfiles = append(res.BFDProfiles, bfdProfileToFRR(p))
+	}
+
+	templates, err := neighborTemplatesByName(fromK8s.Spec.BGP.NeighborTemplates)
+	if err != nil {
+		return nil, err
 	}
 
 	for _, r := range fromK8s.Spec.BGP.Routers {
-		frrRouter, err := routerToFRRConfig(r)
+		frrRouter, err := routerToFRRConfig(r, templates, resources)
 		if err != nil {
 			return nil, err
 		}
+		for _, n := range frrRouter.Neighbors {
+			if n.BFDProfile != "" && !profiles.Has(n.BFDProfile) {
+				return nil, fmt.Errorf("neighbor %s references bfd profile %s which does not exist", n.Name, n.BFDProfile)
+			}
+		}
 		res.Routers = append(res.Routers, frrRouter)
 	}
 	return res, nil
 }
-func routerToFRRConfig(r v1beta1.Router) (*frr.RouterConfig, error) {
+
+func bfdProfileToFRR(p v1beta1.BFDProfile) frr.BFDProfile {
+	optional := func(v uint32) *uint32 {
+		if v == 0 {
+			return nil
+		}
+		return &v
+	}
+	return frr.BFDProfile{
+		Name:             p.Name,
+		ReceiveInterval:  optional(p.ReceiveInterval),
+		TransmitInterval: optional(p.TransmitInterval),
+		DetectMultiplier: optional(p.DetectMultiplier),
+		EchoInterval:     optional(p.EchoInterval),
+		EchoMode:         p.EchoMode,
+		PassiveMode:      p.PassiveMode,
+		MinimumTTL:       optional(p.MinimumTTL),
+	}
+}
+
+func routerToFRRConfig(r v1beta1.Router, templates map[string]v1beta1.NeighborTemplate, resources clusterResources) (*frr.RouterConfig, error) {
 	res := &frr.RouterConfig{
 		MyASN:        r.ASN,
 		RouterID:     r.ID,
@@ -36,6 +169,28 @@ func routerToFRRConfig(r v1beta1.Router) (*frr.RouterConfig, error) {
 		IPV6Prefixes: make([]string, 0),
 	}
 
+	var err error
+	res.GracefulRestart, err = gracefulRestartToFRR(r.GracefulRestart)
+	if err != nil {
+		return nil, fmt.Errorf("invalid graceful restart for router %d: %w", r.ASN, err)
+	}
+	res.Imports, err = importsToFRR(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid imports for router %d: %w", r.ASN, err)
+	}
+	res.RouteTargets, err = routeTargetsToFRR(r.RouteTargets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid route targets for router %d: %w", r.ASN, err)
+	}
+	res.EVPN, err = evpnToFRR(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid evpn for router %d: %w", r.ASN, err)
+	}
+	res.Multipath, err = multipathToFRR(r.Multipath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multipath for router %d: %w", r.ASN, err)
+	}
+
 	for _, p := range r.Prefixes {
 		family := ipfamily.ForCIDRString(p)
 		switch family {
@@ -47,32 +202,216 @@ func routerToFRRConfig(r v1beta1.Router) (*frr.RouterConfig, error) {
 			return nil, fmt.Errorf("unknown ipfamily for %s", p)
 		}
 	}
+	res.AggregatesV4, res.AggregatesV6, err = aggregatesToFRR(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregates for router %d: %w", r.ASN, err)
+	}
+	res.Redistribute, err = redistributeToFRR(r.Redistribute)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redistribution for router %d: %w", r.ASN, err)
+	}
+	// The aggregates can be advertised to the neighbors as any other prefix
+	// of the router.
+	ipv4Prefixes := append(aggregatePrefixes(res.AggregatesV4), res.IPV4Prefixes...)
+	ipv6Prefixes := append(aggregatePrefixes(res.AggregatesV6), res.IPV6Prefixes...)
 
+	groups := map[string]*frr.PeerGroupConfig{}
 	for _, n := range r.Neighbors {
-		frrNeigh, err := neighborToFRR(n, res.IPV4Prefixes, res.IPV6Prefixes)
+		var group *frr.PeerGroupConfig
+		if n.Template != "" {
+			t, ok := templates[n.Template]
+			if !ok {
+				return nil, fmt.Errorf("neighbor %s references template %s which does not exist", neighborPeer(n), n.Template)
+			}
+			group, ok = groups[t.Name]
+			if !ok {
+				var err error
+				group, err = peerGroupToFRR(t, resources.PasswordSecrets)
+				if err != nil {
+					return nil, err
+				}
+				groups[t.Name] = group
+			}
+			n = withTemplate(n, t)
+		}
+		frrNeigh, err := neighborToFRR(n, r.ASN, r.VRF, ipv4Prefixes, ipv6Prefixes, resources)
+		if err != nil {
+			return nil, err
+		}
+		frrNeigh.Template = group
+		frrNeigh.AdvertiseRedistributed = len(res.Redistribute) > 0 && n.ToAdvertise.Allowed.Mode == v1beta1.AllowAll
+		res.Neighbors = append(res.Neighbors, frrNeigh)
+	}
+	res.PeerGroups = sortedPeerGroups(groups)
+
+	for _, d := range r.DynamicNeighbors {
+		frrNeigh, err := dynamicNeighborToFRR(d, r.ASN, r.VRF, ipv4Prefixes, ipv6Prefixes, resources.PasswordSecrets)
 		if err != nil {
 			return nil, err
 		}
+		frrNeigh.AdvertiseRedistributed = len(res.Redistribute) > 0 && d.ToAdvertise.Allowed.Mode == v1beta1.AllowAll
 		res.Neighbors = append(res.Neighbors, frrNeigh)
 	}
 
+	if err := validateListenRanges(res.Neighbors); err != nil {
+		return nil, err
+	}
+	if err := validatePeerGroups(res.PeerGroups, res.Neighbors); err != nil {
+		return nil, err
+	}
+
 	return res, nil
 }
 
-func neighborToFRR(n v1beta1.Neighbor, ipv4Prefixes, ipv6Prefixes []string) (*frr.NeighborConfig, error) {
-	neighborFamily, err := ipfamily.ForAddresses(n.Address)
+func neighborToFRR(n v1beta1.Neighbor, myASN uint32, vrf string, ipv4Prefixes, ipv6Prefixes []string, resources clusterResources) (*frr.NeighborConfig, error) {
+	remoteAS, err := remoteASFor(n.ASN, n.DynamicASN, neighborPeer(n))
+	if err != nil {
+		return nil, err
+	}
+	peer, neighborFamily, err := peerFor(n)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find ipfamily for %s, %w", n.Address, err)
+		return nil, err
 	}
 	res := &frr.NeighborConfig{
-		Name: neighborName(n.ASN, n.Address),
-		ASN:  n.ASN,
-		Addr: n.Address,
-		Port: n.Port,
-		// Password:       n.Password, TODO password as secret
+		Name:           neighborName(remoteAS, peer),
+		ASN:            n.ASN,
+		DynamicASN:     string(n.DynamicASN),
+		Addr:           n.Address,
+		Iface:          n.Interface,
+		Port:           n.Port,
 		Advertisements: make([]*frr.AdvertisementConfig, 0),
 		IPFamily:       neighborFamily,
-		EBGPMultiHop:   n.EBGPMultiHop,
+		EBGPMultiHop:   n.EBGPMultiHop != nil && *n.EBGPMultiHop,
+		BFDProfile:     n.BFDProfile,
+		VRFName:        vrf,
+	}
+	if n.Interface != "" && res.EBGPMultiHop {
+		return nil, fmt.Errorf("neighbor %s is an unnumbered neighbor and can't be multihop", res.Name)
+	}
+	if n.GracefulRestartMode != "" && !gracefulRestartModes.Has(n.GracefulRestartMode) {
+		return nil, fmt.Errorf("neighbor %s has invalid graceful restart mode %s, must be one of %v", res.Name, n.GracefulRestartMode, sets.List(gracefulRestartModes))
+	}
+	res.GracefulRestart = string(n.GracefulRestartMode)
+	res.MaximumPrefixV4, res.MaximumPrefixV6, err = maximumPrefixesToFRR(n.MaximumPrefixes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid maximum prefixes for neighbor %s: %w", res.Name, err)
+	}
+	res.SrcAddr, err = updateSourceFor(n.UpdateSource, neighborFamily, resources.NodeAnnotations)
+	if err != nil {
+		return nil, fmt.Errorf("invalid update source for neighbor %s: %w", res.Name, err)
+	}
+
+	err = setSessionProperties(res, n, myASN, ipv4Prefixes, ipv6Prefixes, resources.PasswordSecrets)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// dynamicNeighborToFRR converts the given dynamic neighbor to a peer group
+// accepting the sessions from the peers in its listen ranges.
+func dynamicNeighborToFRR(d v1beta1.DynamicNeighbor, myASN uint32, vrf string, ipv4Prefixes, ipv6Prefixes []string, passwordSecrets map[string]v1.Secret) (*frr.NeighborConfig, error) {
+	if !validPeerGroupName(d.Name) {
+		return nil, fmt.Errorf("invalid name %q for dynamic neighbor", d.Name)
+	}
+	n := v1beta1.Neighbor{
+		ASN:            d.ASN,
+		DynamicASN:     d.DynamicASN,
+		PasswordSecret: d.PasswordSecret,
+		HoldTime:       d.HoldTime,
+		KeepaliveTime:  d.KeepaliveTime,
+		EBGPMultiHop:   &d.EBGPMultiHop,
+		BFDProfile:     d.BFDProfile,
+		ToAdvertise:    d.ToAdvertise,
+		ToReceive:      d.ToReceive,
+	}
+	remoteAS, err := remoteASFor(d.ASN, d.DynamicASN, d.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.ListenRanges) == 0 {
+		return nil, fmt.Errorf("dynamic neighbor %s has no listen ranges", d.Name)
+	}
+	ranges := sets.New[string]()
+	for _, r := range d.ListenRanges {
+		_, cidr, err := net.ParseCIDR(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid listen range %s for dynamic neighbor %s: %w", r, d.Name, err)
+		}
+		ranges.Insert(cidr.String())
+	}
+	listenRanges := sets.List(ranges)
+
+	res := &frr.NeighborConfig{
+		Name:           neighborName(remoteAS, d.Name),
+		ASN:            d.ASN,
+		DynamicASN:     string(d.DynamicASN),
+		PeerGroup:      d.Name,
+		ListenRanges:   listenRanges,
+		Advertisements: make([]*frr.AdvertisementConfig, 0),
+		IPFamily:       listenRangesFamily(listenRanges),
+		EBGPMultiHop:   d.EBGPMultiHop,
+		BFDProfile:     d.BFDProfile,
+		VRFName:        vrf,
+	}
+
+	err = setSessionProperties(res, n, myASN, ipv4Prefixes, ipv6Prefixes, passwordSecrets)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// validateListenRanges checks that each listen range is associated to one
+// dynamic neighbor only, as FRR would not know which one to assign the
+// incoming sessions to. Also, the names of the dynamic neighbors must not
+// clash with the other neighbors.
+func validateListenRanges(neighbors []*frr.NeighborConfig) error {
+	ranges := map[string]string{}
+	ids := sets.New[string]()
+	for _, n := range neighbors {
+		if ids.Has(n.ID()) && n.PeerGroup != "" {
+			return fmt.Errorf("dynamic neighbor %s clashes with another neighbor", n.PeerGroup)
+		}
+		ids.Insert(n.ID())
+		for _, r := range n.ListenRanges {
+			if group, ok := ranges[r]; ok && group != n.PeerGroup {
+				return fmt.Errorf("listen range %s associated to multiple dynamic neighbors (%s, %s)", r, group, n.PeerGroup)
+			}
+			ranges[r] = n.PeerGroup
+		}
+	}
+	return nil
+}
+
+// listenRangesFamily returns the ip family of the sessions accepted from
+// the given ranges.
+func listenRangesFamily(ranges []string) ipfamily.Family {
+	families := sets.New[ipfamily.Family]()
+	for _, r := range ranges {
+		families.Insert(ipfamily.ForCIDRString(r))
+	}
+	if families.Len() > 1 {
+		return ipfamily.DualStack
+	}
+	return sets.List(families)[0]
+}
+
+// setSessionProperties sets the properties shared by all the kinds of
+// neighbors, that are the timers, the password, the prefixes to advertise
+// and the ones to receive.
+func setSessionProperties(res *frr.NeighborConfig, n v1beta1.Neighbor, myASN uint32, ipv4Prefixes, ipv6Prefixes []string, passwordSecrets map[string]v1.Secret) error {
+	var err error
+	res.HoldTime, res.KeepaliveTime, err = timersToFRR(n.HoldTime, n.KeepaliveTime)
+	if err != nil {
+		return fmt.Errorf("invalid timers for neighbor %s: %w", res.Name, err)
+	}
+
+	if n.PasswordSecret.Name != "" {
+		res.Password, err = passwordForSecret(n.PasswordSecret, passwordSecrets)
+		if err != nil {
+			return fmt.Errorf("failed to get the password for neighbor %s: %w", res.Name, err)
+		}
 	}
 
 	if n.ToAdvertise.Allowed.Mode == v1beta1.AllowAll {
@@ -84,24 +423,539 @@ func neighborToFRR(n v1beta1.Neighbor, ipv4Prefixes, ipv6Prefixes []string) (*fr
 			res.Advertisements = append(res.Advertisements, &frr.AdvertisementConfig{Prefix: p, IPFamily: ipfamily.IPv6})
 			res.HasV6Advertisements = true
 		}
+	} else {
+		toAdvertise := n.ToAdvertise.Allowed.Prefixes
+		for _, s := range n.ToAdvertise.Allowed.PrefixSelectors {
+			selector, err := prefixSelectorToFRR(s)
+			if err != nil {
+				return fmt.Errorf("invalid advertisements for neighbor %s: %w", res.Name, err)
+			}
+			toAdvertise = append(toAdvertise, routerPrefixesMatching(selector, ipv4Prefixes, ipv6Prefixes)...)
+		}
+
+		added := sets.New[string]()
+		for _, p := range toAdvertise {
+			if added.Has(p) {
+				continue
+			}
+			added.Insert(p)
+			family := ipfamily.ForCIDRString(p)
+			switch family {
+			case ipfamily.IPv4:
+				res.HasV4Advertisements = true
+			case ipfamily.IPv6:
+				res.HasV6Advertisements = true
+			}
+			res.Advertisements = append(res.Advertisements, &frr.AdvertisementConfig{Prefix: p, IPFamily: family})
+		}
+	}
+
+	err = setAdvertisementsProperties(res.Advertisements, n.ToAdvertise, myASN)
+	if err != nil {
+		return fmt.Errorf("invalid advertisements for neighbor %s: %w", res.Name, err)
+	}
 
+	res.Incoming, err = allowedIncomingToFRR(n.ToReceive)
+	if err != nil {
+		return fmt.Errorf("invalid prefixes to receive for neighbor %s: %w", res.Name, err)
+	}
+
+	return nil
+}
+
+// allowedIncomingToFRR converts the prefixes allowed to be received from
+// a neighbor, splitting them per ip family.
+func allowedIncomingToFRR(toReceive v1beta1.Receive) (frr.AllowedIn, error) {
+	res := frr.AllowedIn{}
+	if toReceive.Allowed.Mode == v1beta1.AllowAll {
+		res.All = true
 		return res, nil
 	}
 
-	for _, p := range n.ToAdvertise.Allowed.Prefixes {
-		family := ipfamily.ForCIDRString(p)
-		switch family {
+	filters, err := allowedPrefixesToFRR(toReceive.Allowed)
+	if err != nil {
+		return frr.AllowedIn{}, err
+	}
+	for _, f := range filters {
+		switch f.IPFamily {
 		case ipfamily.IPv4:
-			res.HasV4Advertisements = true
+			res.PrefixesV4 = append(res.PrefixesV4, f)
 		case ipfamily.IPv6:
-			res.HasV6Advertisements = true
+			res.PrefixesV6 = append(res.PrefixesV6, f)
+		}
+	}
+	return res, nil
+}
+
+// allowedPrefixesToFRR converts both the exact prefixes and the prefix
+// selectors of the given allowed prefixes to filters.
+func allowedPrefixesToFRR(allowed v1beta1.AllowedPrefixes) ([]frr.IncomingFilter, error) {
+	res := make([]frr.IncomingFilter, 0, len(allowed.Prefixes)+len(allowed.PrefixSelectors))
+	for _, p := range allowed.Prefixes {
+		f, err := prefixSelectorToFRR(v1beta1.PrefixSelector{Prefix: p})
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, f)
+	}
+	for _, s := range allowed.PrefixSelectors {
+		f, err := prefixSelectorToFRR(s)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, f)
+	}
+	return res, nil
+}
+
+// setAdvertisementsProperties sets the local preference, the communities,
+// the as path prepending, the med and the origin of the given advertisements. An error is
+// returned if the properties are associated to prefixes not allowed to be
+// advertised.
+func setAdvertisementsProperties(advertisements []*frr.AdvertisementConfig, toAdvertise v1beta1.Advertise, myASN uint32) error {
+	byPrefix := map[string]*frr.AdvertisementConfig{}
+	for _, a := range advertisements {
+		byPrefix[a.Prefix] = a
+	}
+
+	neighborPrepend, err := asPathPrependToFRR(toAdvertise.ASPathPrepend, myASN)
+	if err != nil {
+		return err
+	}
+	prefixPrepends := map[string]frr.ASPathPrepend{}
+	for _, pp := range toAdvertise.PrefixesWithASPathPrepend {
+		prepend, err := asPathPrependToFRR(pp.ASPathPrepend, myASN)
+		if err != nil {
+			return err
+		}
+		for _, p := range pp.Prefixes {
+			if _, ok := byPrefix[p]; !ok {
+				return fmt.Errorf("as path prepend associated to prefix %s, which is not allowed", p)
+			}
+			if existing, ok := prefixPrepends[p]; ok && existing != prepend {
+				return fmt.Errorf("multiple as path prepends (%s != %s) specified for prefix %s", existing, prepend, p)
+			}
+			prefixPrepends[p] = prepend
+		}
+	}
+	for _, a := range advertisements {
+		a.ASPathPrepend = neighborPrepend
+		if prepend, ok := prefixPrepends[a.Prefix]; ok {
+			a.ASPathPrepend = prepend
 		}
-		res.Advertisements = append(res.Advertisements, &frr.AdvertisementConfig{Prefix: p, IPFamily: family})
 	}
 
+	err = setMEDs(advertisements, byPrefix, toAdvertise)
+	if err != nil {
+		return err
+	}
+	err = setOrigins(advertisements, byPrefix, toAdvertise)
+	if err != nil {
+		return err
+	}
+
+	for _, lp := range toAdvertise.PrefixesWithLocalPref {
+		if lp.LocalPref < 0 || int64(lp.LocalPref) > math.MaxUint32 {
+			return fmt.Errorf("invalid local pref %d", lp.LocalPref)
+		}
+		for _, p := range lp.Prefixes {
+			a, ok := byPrefix[p]
+			if !ok {
+				return fmt.Errorf("local pref %d associated to prefix %s, which is not allowed", lp.LocalPref, p)
+			}
+			if a.LocalPref != 0 && a.LocalPref != uint32(lp.LocalPref) {
+				return fmt.Errorf("multiple local prefs (%d != %d) specified for prefix %s", a.LocalPref, lp.LocalPref, p)
+			}
+			a.LocalPref = uint32(lp.LocalPref)
+		}
+	}
+
+	for _, c := range toAdvertise.PrefixesWithCommunity {
+		communityType, community, err := parseCommunity(c.Community)
+		if err != nil {
+			return err
+		}
+		for _, p := range c.Prefixes {
+			a, ok := byPrefix[p]
+			if !ok {
+				return fmt.Errorf("community %s associated to prefix %s, which is not allowed", c.Community, p)
+			}
+			switch communityType {
+			case standardCommunity:
+				a.Communities = append(a.Communities, community)
+			case largeCommunity:
+				a.LargeCommunities = append(a.LargeCommunities, community)
+			case extendedCommunity:
+				a.ExtendedCommunities = append(a.ExtendedCommunities, community)
+			}
+		}
+	}
+
+	for _, a := range advertisements {
+		a.Communities = sortedCommunities(a.Communities)
+		a.LargeCommunities = sortedCommunities(a.LargeCommunities)
+		a.ExtendedCommunities = sortedCommunities(a.ExtendedCommunities)
+	}
+	return nil
+}
+
+// setMEDs sets the multi-exit discriminator of the given advertisements,
+// with the ones specified per prefix overriding the one of the neighbor.
+func setMEDs(advertisements []*frr.AdvertisementConfig, byPrefix map[string]*frr.AdvertisementConfig, toAdvertise v1beta1.Advertise) error {
+	if toAdvertise.MED != nil {
+		for _, a := range advertisements {
+			med := *toAdvertise.MED
+			a.MED = &med
+		}
+	}
+
+	overridden := sets.New[string]()
+	for _, m := range toAdvertise.PrefixesWithMED {
+		for _, p := range m.Prefixes {
+			a, ok := byPrefix[p]
+			if !ok {
+				return fmt.Errorf("med %d associated to prefix %s, which is not allowed", m.MED, p)
+			}
+			if overridden.Has(p) && *a.MED != m.MED {
+				return fmt.Errorf("multiple meds (%d != %d) specified for prefix %s", *a.MED, m.MED, p)
+			}
+			med := m.MED
+			a.MED = &med
+			overridden.Insert(p)
+		}
+	}
+	return nil
+}
+
+// bgpOrigins are the values of the origin attribute, as named by FRR.
+var bgpOrigins = sets.New("igp", "egp", "incomplete")
+
+// setOrigins sets the origin of the given advertisements, with the ones
+// specified per prefix overriding the one of the neighbor.
+func setOrigins(advertisements []*frr.AdvertisementConfig, byPrefix map[string]*frr.AdvertisementConfig, toAdvertise v1beta1.Advertise) error {
+	if toAdvertise.Origin != "" {
+		if !bgpOrigins.Has(toAdvertise.Origin) {
+			return fmt.Errorf("invalid origin %s, must be one of %v", toAdvertise.Origin, sets.List(bgpOrigins))
+		}
+		for _, a := range advertisements {
+			a.Origin = toAdvertise.Origin
+		}
+	}
+
+	overridden := sets.New[string]()
+	for _, o := range toAdvertise.PrefixesWithOrigin {
+		if !bgpOrigins.Has(o.Origin) {
+			return fmt.Errorf("invalid origin %s, must be one of %v", o.Origin, sets.List(bgpOrigins))
+		}
+		for _, p := range o.Prefixes {
+			a, ok := byPrefix[p]
+			if !ok {
+				return fmt.Errorf("origin %s associated to prefix %s, which is not allowed", o.Origin, p)
+			}
+			if overridden.Has(p) && a.Origin != o.Origin {
+				return fmt.Errorf("multiple origins (%s != %s) specified for prefix %s", a.Origin, o.Origin, p)
+			}
+			a.Origin = o.Origin
+			overridden.Insert(p)
+		}
+	}
+	return nil
+}
+
+// maxASPathPrepend is the maximum number of times an AS number can be
+// prepended. Longer paths are not more effective in making a route less
+// preferred, and only make the updates bigger.
+const maxASPathPrepend = 10
+
+// asPathPrependToFRR validates the given prepending, defaulting the AS
+// number to the one of the router.
+func asPathPrependToFRR(p v1beta1.ASPathPrepend, myASN uint32) (frr.ASPathPrepend, error) {
+	if p.Times < 0 || p.Times > maxASPathPrepend {
+		return frr.ASPathPrepend{}, fmt.Errorf("invalid as path prepend times %d, must be between 0 and %d", p.Times, maxASPathPrepend)
+	}
+	if p.Times == 0 {
+		return frr.ASPathPrepend{}, nil
+	}
+	res := frr.ASPathPrepend{ASN: p.ASN, Times: p.Times}
+	if res.ASN == 0 {
+		res.ASN = myASN
+	}
 	return res, nil
 }
 
-func neighborName(ASN uint32, peerAddr string) string {
-	return fmt.Sprintf("%d@%s", ASN, peerAddr)
+// sortedCommunities returns the given communities deduplicated and
+// sorted, or nil if there are none.
+func sortedCommunities(communities []string) []string {
+	if len(communities) == 0 {
+		return nil
+	}
+	return sets.List(sets.New(communities...))
+}
+
+var gracefulRestartModes = sets.New(v1beta1.GracefulRestartRestart, v1beta1.GracefulRestartHelper, v1beta1.GracefulRestartDisabled)
+
+// maxGracefulRestartTime is the maximum value FRR accepts for both the
+// restart and the stale path times.
+const maxGracefulRestartTime = 4095
+
+// gracefulRestartToFRR validates the given graceful restart settings and
+// converts the times to seconds.
+func gracefulRestartToFRR(gr v1beta1.GracefulRestart) (frr.GracefulRestartConfig, error) {
+	if gr.Mode != "" && !gracefulRestartModes.Has(gr.Mode) {
+		return frr.GracefulRestartConfig{}, fmt.Errorf("invalid mode %s, must be one of %v", gr.Mode, sets.List(gracefulRestartModes))
+	}
+	if gr.RestartTime.Duration < 0 || gr.RestartTime.Duration > maxGracefulRestartTime*time.Second {
+		return frr.GracefulRestartConfig{}, fmt.Errorf("invalid restart time %s, must be between 0s and %ds", gr.RestartTime.Duration, maxGracefulRestartTime)
+	}
+	if gr.StalePathTime.Duration < 0 || gr.StalePathTime.Duration > maxGracefulRestartTime*time.Second {
+		return frr.GracefulRestartConfig{}, fmt.Errorf("invalid stale path time %s, must be between 0s and %ds", gr.StalePathTime.Duration, maxGracefulRestartTime)
+	}
+	return frr.GracefulRestartConfig{
+		Mode:          string(gr.Mode),
+		RestartTime:   uint64(gr.RestartTime.Duration / time.Second),
+		StalePathTime: uint64(gr.StalePathTime.Duration / time.Second),
+	}, nil
+}
+
+// maxPaths is the maximum number of paths FRR installs for the same prefix,
+// as per the multipath limit it is built with.
+const maxPaths = 64
+
+// multipathToFRR validates the given equal cost multipath settings.
+func multipathToFRR(m v1beta1.Multipath) (frr.MultipathConfig, error) {
+	if m.MaximumPathsEBGP > maxPaths {
+		return frr.MultipathConfig{}, fmt.Errorf("invalid maximum ebgp paths %d, must be at most %d", m.MaximumPathsEBGP, maxPaths)
+	}
+	if m.MaximumPathsIBGP > maxPaths {
+		return frr.MultipathConfig{}, fmt.Errorf("invalid maximum ibgp paths %d, must be at most %d", m.MaximumPathsIBGP, maxPaths)
+	}
+	return frr.MultipathConfig{
+		MaximumPathsEBGP:     m.MaximumPathsEBGP,
+		MaximumPathsIBGP:     m.MaximumPathsIBGP,
+		ASPathMultipathRelax: m.ASPathMultipathRelax,
+	}, nil
+}
+
+// timersToFRR validates the hold and keepalive times according to RFC4271,
+// and returns them in seconds. When only one of the two is set, the other
+// one is derived from it keeping the 3:1 ratio suggested by the RFC. When
+// none is set, FRR's defaults are used and zero is returned for both.
+func timersToFRR(holdTime, keepaliveTime metav1.Duration) (uint64, uint64, error) {
+	if holdTime.Duration < 0 || keepaliveTime.Duration < 0 {
+		return 0, 0, fmt.Errorf("hold time and keepalive time can't be negative")
+	}
+	if holdTime.Duration%time.Second != 0 {
+		return 0, 0, fmt.Errorf("hold time %s must be a whole number of seconds", holdTime.Duration)
+	}
+	if keepaliveTime.Duration%time.Second != 0 {
+		return 0, 0, fmt.Errorf("keepalive time %s must be a whole number of seconds", keepaliveTime.Duration)
+	}
+	hold := uint64(holdTime.Duration / time.Second)
+	keepalive := uint64(keepaliveTime.Duration / time.Second)
+
+	switch {
+	case hold == 0 && keepalive == 0:
+		return 0, 0, nil
+	case keepalive == 0:
+		keepalive = hold / 3
+	case hold == 0:
+		hold = keepalive * 3
+	}
+
+	if hold < 3 {
+		return 0, 0, fmt.Errorf("hold time %ds must be at least 3s", hold)
+	}
+	if hold > math.MaxUint16 {
+		return 0, 0, fmt.Errorf("hold time %ds must be at most %ds", hold, math.MaxUint16)
+	}
+	if keepalive >= hold {
+		return 0, 0, fmt.Errorf("keepalive time %ds must be lower than the hold time %ds", keepalive, hold)
+	}
+	return hold, keepalive, nil
+}
+
+// maximumPrefixesToFRR validates the given limits of the accepted prefixes
+// and returns the ones of the ipv4 and of the ipv6 families, if any.
+func maximumPrefixesToFRR(limits []v1beta1.MaximumPrefix) (*frr.MaximumPrefixConfig, *frr.MaximumPrefixConfig, error) {
+	var v4, v6 *frr.MaximumPrefixConfig
+	for _, l := range limits {
+		if l.Limit == 0 {
+			return nil, nil, fmt.Errorf("the limit for %s must be greater than 0", l.IPFamily)
+		}
+		if l.RestartAfter.Duration < 0 || l.RestartAfter.Duration%time.Minute != 0 {
+			return nil, nil, fmt.Errorf("restart after %s for %s must be a positive number of minutes", l.RestartAfter.Duration, l.IPFamily)
+		}
+		restart := uint64(l.RestartAfter.Duration / time.Minute)
+		if restart > math.MaxUint16 {
+			return nil, nil, fmt.Errorf("restart after %dm for %s must be at most %dm", restart, l.IPFamily, math.MaxUint16)
+		}
+		if l.WarningOnly && restart != 0 {
+			return nil, nil, fmt.Errorf("warning only and restart after can't be both specified for %s", l.IPFamily)
+		}
+
+		res := &frr.MaximumPrefixConfig{
+			Limit:       l.Limit,
+			WarningOnly: l.WarningOnly,
+			Restart:     restart,
+		}
+		switch ipfamily.Family(l.IPFamily) {
+		case ipfamily.IPv4:
+			if v4 != nil {
+				return nil, nil, fmt.Errorf("multiple limits specified for %s", l.IPFamily)
+			}
+			v4 = res
+		case ipfamily.IPv6:
+			if v6 != nil {
+				return nil, nil, fmt.Errorf("multiple limits specified for %s", l.IPFamily)
+			}
+			v6 = res
+		default:
+			return nil, nil, fmt.Errorf("invalid ip family %q, must be one of %s, %s", l.IPFamily, ipfamily.IPv4, ipfamily.IPv6)
+		}
+	}
+	return v4, v6, nil
+}
+
+// passwordForSecret returns the password stored in the basic-auth secret
+// referenced by ref, which must live in the namespace of the daemon.
+func passwordForSecret(ref v1.SecretReference, passwordSecrets map[string]v1.Secret) (string, error) {
+	secret, ok := passwordSecrets[ref.Name]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", ref.Name)
+	}
+	if ref.Namespace != "" && ref.Namespace != secret.Namespace {
+		return "", fmt.Errorf("secret %s/%s must be in the namespace of the daemon (%s)", ref.Namespace, ref.Name, secret.Namespace)
+	}
+	if secret.Type != v1.SecretTypeBasicAuth {
+		return "", fmt.Errorf("secret type mismatch on %s/%s, type %s is expected", secret.Namespace, secret.Name, v1.SecretTypeBasicAuth)
+	}
+	password, ok := secret.Data[v1.BasicAuthPasswordKey]
+	if !ok {
+		return "", fmt.Errorf("password not specified in secret %s/%s", secret.Namespace, secret.Name)
+	}
+	return string(password), nil
+}
+
+func neighborName(remoteAS string, peer string) string {
+	return fmt.Sprintf("%s@%s", remoteAS, peer)
+}
+
+// remoteASFor validates the AS of the given peer, that must be either
+// set explicitly or dynamic, and returns it as rendered in the neighbor name.
+func remoteASFor(asn uint32, dynamicASN v1beta1.DynamicASNMode, peer string) (string, error) {
+	switch {
+	case asn != 0 && dynamicASN != "":
+		return "", fmt.Errorf("neighbor %s has both asn %d and dynamicASN %s specified", peer, asn, dynamicASN)
+	case dynamicASN != "":
+		if dynamicASN != v1beta1.InternalASNMode && dynamicASN != v1beta1.ExternalASNMode {
+			return "", fmt.Errorf("neighbor %s has invalid dynamicASN %s, must be either %s or %s", peer, dynamicASN, v1beta1.InternalASNMode, v1beta1.ExternalASNMode)
+		}
+		return string(dynamicASN), nil
+	case asn == 0:
+		return "", fmt.Errorf("neighbor %s has no asn or dynamicASN specified", peer)
+	}
+	return strconv.FormatUint(uint64(asn), 10), nil
+}
+
+// peerFor validates the address or the interface of the given neighbor,
+// that are mutually exclusive, and returns the one in use together with the
+// ip family of the session. Unnumbered neighbors carry both the families.
+func peerFor(n v1beta1.Neighbor) (string, ipfamily.Family, error) {
+	switch {
+	case n.Address != "" && n.Interface != "":
+		return "", "", fmt.Errorf("neighbor with address %s and interface %s, only one must be specified", n.Address, n.Interface)
+	case n.Interface != "":
+		if !validInterfaceName(n.Interface) {
+			return "", "", fmt.Errorf("invalid interface name %q for neighbor", n.Interface)
+		}
+		return n.Interface, ipfamily.DualStack, nil
+	case n.Address == "":
+		return "", "", fmt.Errorf("neighbor with neither address nor interface specified")
+	}
+	family, err := ipfamily.ForAddresses(n.Address)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to find ipfamily for %s, %w", n.Address, err)
+	}
+	return n.Address, family, nil
+}
+
+// updateSourceFor validates the given update source and returns either the
+// local address of the session or the interface to take it from. The address
+// is required to be of the same ip family of the neighbor, unless the
+// neighbor is dual stack.
+func updateSourceFor(s v1beta1.UpdateSource, neighborFamily ipfamily.Family, nodeAnnotations map[string]string) (string, error) {
+	set := 0
+	for _, v := range []string{s.Address, s.Interface, s.NodeAnnotation} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return "", fmt.Errorf("only one of address, interface and node annotation must be specified")
+	}
+
+	switch {
+	case s.Interface != "":
+		if !validInterfaceName(s.Interface) {
+			return "", fmt.Errorf("invalid interface name %q", s.Interface)
+		}
+		return s.Interface, nil
+	case s.Address != "":
+		ip := net.ParseIP(s.Address)
+		if ip == nil {
+			return "", fmt.Errorf("invalid address %s", s.Address)
+		}
+		if neighborFamily != ipfamily.DualStack && ipfamily.ForAddress(ip) != neighborFamily {
+			return "", fmt.Errorf("address %s does not match the ip family of the neighbor (%s)", s.Address, neighborFamily)
+		}
+		return ip.String(), nil
+	case s.NodeAnnotation != "":
+		value, ok := nodeAnnotations[s.NodeAnnotation]
+		if !ok {
+			return "", fmt.Errorf("node annotation %s not found", s.NodeAnnotation)
+		}
+		for _, a := range strings.Split(value, ",") {
+			ip := net.ParseIP(strings.TrimSpace(a))
+			if ip == nil {
+				return "", fmt.Errorf("invalid address %s in node annotation %s", a, s.NodeAnnotation)
+			}
+			if neighborFamily == ipfamily.DualStack || ipfamily.ForAddress(ip) == neighborFamily {
+				return ip.String(), nil
+			}
+		}
+		return "", fmt.Errorf("no address of the ip family of the neighbor (%s) in node annotation %s", neighborFamily, s.NodeAnnotation)
+	}
+	return "", nil
+}
+
+// neighborPeer returns the address or the interface of the neighbor,
+// to be used in the error messages.
+func neighborPeer(n v1beta1.Neighbor) string {
+	if n.Interface != "" {
+		return n.Interface
+	}
+	return n.Address
+}
+
+// validPeerGroupName tells if the given name can be used as the name of a
+// peer group, which must not be confused with an address by FRR.
+func validPeerGroupName(name string) bool {
+	if name == "" || net.ParseIP(name) != nil {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// validInterfaceName tells if the given name is a valid linux interface name.
+func validInterfaceName(name string) bool {
+	// The max length is IFNAMSIZ minus the terminating null byte.
+	if len(name) > 15 || name == "." || name == ".." {
+		return false
+	}
+	return !strings.ContainsAny(name, "/: \t\n")
 }
@@ -3,20 +3,29 @@
 package controller
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	v1beta1 "github.com/metallb/frrk8s/api/v1beta1"
 	"github.com/metallb/frrk8s/internal/frr"
 	"github.com/metallb/frrk8s/internal/ipfamily"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestConversion(t *testing.T) {
+	receiveInterval, detectMultiplier := uint32(100), uint32(5)
+	med, prefixMED := uint32(100), uint32(200)
+	multiHop, noMultiHop := true, false
 	tests := []struct {
-		name     string
-		fromK8s  []v1beta1.FRRConfiguration
-		expected *frr.Config
-		err      error
+		name        string
+		fromK8s     []v1beta1.FRRConfiguration
+		secrets     map[string]v1.Secret
+		annotations map[string]string
+		expected    *frr.Config
+		err         error
 	}{
 
 		{
@@ -64,6 +73,7 @@ func TestConversion(t *testing.T) {
 						IPV6Prefixes: []string{},
 					},
 				},
+				BFDProfiles: []frr.BFDProfile{},
 			},
 			err: nil,
 		},
@@ -148,6 +158,7 @@ func TestConversion(t *testing.T) {
 								Addr:           "2001:db8::4",
 								Port:           179,
 								Advertisements: []*frr.AdvertisementConfig{},
+								VRFName:        "vrf2",
 							},
 						},
 						VRF:          "vrf2",
@@ -155,6 +166,7 @@ func TestConversion(t *testing.T) {
 						IPV6Prefixes: []string{"2001:db8::/64"},
 					},
 				},
+				BFDProfiles: []frr.BFDProfile{},
 			},
 			err: nil,
 		},
@@ -202,6 +214,7 @@ func TestConversion(t *testing.T) {
 						IPV6Prefixes: []string{"2001:db8::/64"},
 					},
 				},
+				BFDProfiles: []frr.BFDProfile{},
 			},
 			err: nil,
 		},
@@ -211,7 +224,8 @@ func TestConversion(t *testing.T) {
 				{},
 			},
 			expected: &frr.Config{
-				Routers: []*frr.RouterConfig{},
+				Routers:     []*frr.RouterConfig{},
+				BFDProfiles: []frr.BFDProfile{},
 			},
 			err: nil,
 		},
@@ -253,6 +267,7 @@ func TestConversion(t *testing.T) {
 								Addr:           "192.0.2.16",
 								Port:           179,
 								Advertisements: []*frr.AdvertisementConfig{},
+								VRFName:        "vrf1",
 							},
 						},
 						VRF:          "vrf1",
@@ -260,6 +275,7 @@ func TestConversion(t *testing.T) {
 						IPV6Prefixes: []string{},
 					},
 				},
+				BFDProfiles: []frr.BFDProfile{},
 			},
 			err: nil,
 		},
@@ -318,6 +334,7 @@ func TestConversion(t *testing.T) {
 						IPV6Prefixes: []string{},
 					},
 				},
+				BFDProfiles: []frr.BFDProfile{},
 			},
 			err: nil,
 		},
@@ -417,14 +434,3964 @@ func TestConversion(t *testing.T) {
 						IPV6Prefixes: []string{"2001:db8::/64"},
 					},
 				},
+				BFDProfiles: []frr.BFDProfile{},
 			},
 			err: nil,
 		},
+		{
+			name: "Multiple configs, same router",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									ID:  "192.0.2.20",
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											Port:    179,
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.3.0/24"},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.3.0/24"},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											Port:    179,
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+												},
+											},
+										},
+										{
+											ASN:     65042,
+											Address: "192.0.2.22",
+											Port:    179,
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN:    65040,
+						RouterID: "192.0.2.20",
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Name:     "65041@192.0.2.21",
+								ASN:      65041,
+								Addr:     "192.0.2.21",
+								Port:     179,
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.2.0/24",
+									},
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.3.0/24",
+									},
+								},
+								HasV4Advertisements: true,
+							},
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65042@192.0.2.22",
+								ASN:            65042,
+								Addr:           "192.0.2.22",
+								Port:           179,
+								Advertisements: []*frr.AdvertisementConfig{},
+							},
+						},
+						IPV4Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Multiple configs, different vrfs",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									VRF: "red",
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65041@192.0.2.21",
+								ASN:            65041,
+								Addr:           "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+					{
+						MyASN: 65040,
+						VRF:   "red",
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65041@192.0.2.21",
+								ASN:            65041,
+								Addr:           "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{},
+								VRFName:        "red",
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Multiple configs, different asns for the same vrf",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65041,
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("different asns (65040 != 65041) specified for same vrf: "),
+		},
+		{
+			name: "Multiple configs, same neighbor with different asns",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65042,
+											Address: "192.0.2.21",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("multiple asns specified for 192.0.2.21"),
+		},
+		{
+			name: "Multiple configs, same neighbor with different ports",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											Port:    179,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											Port:    180,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("multiple ports specified for 192.0.2.21"),
+		},
+		{
+			name: "Multiple configs with raw config",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "b",
+						Namespace: "default",
+					},
+					Spec: v1beta1.FRRConfigurationSpec{
+						Raw: v1beta1.RawConfig{
+							Priority: 5,
+							Config:   "bfd\n",
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "c",
+						Namespace: "default",
+					},
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+								},
+							},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "a",
+						Namespace: "default",
+					},
+					Spec: v1beta1.FRRConfigurationSpec{
+						Raw: v1beta1.RawConfig{
+							Priority: 5,
+							Config:   "router bgp 65040\n  bgp router-id 192.0.2.20",
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "d",
+						Namespace: "default",
+					},
+					Spec: v1beta1.FRRConfigurationSpec{
+						Raw: v1beta1.RawConfig{
+							Priority: 1,
+							Config:   "ip prefix-list foo permit 192.0.2.0/24",
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN:        65040,
+						Neighbors:    []*frr.NeighborConfig{},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+				ExtraConfig: "ip prefix-list foo permit 192.0.2.0/24\nrouter bgp 65040\n  bgp router-id 192.0.2.20\nbfd",
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with password",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											PasswordSecret: v1.SecretReference{
+												Name:      "secret1",
+												Namespace: "frr-k8s-system",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			secrets: map[string]v1.Secret{
+				"secret1": {
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret1",
+						Namespace: "frr-k8s-system",
+					},
+					Type: v1.SecretTypeBasicAuth,
+					Data: map[string][]byte{
+						"password": []byte("nicepass"),
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65001,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65002@192.0.2.2",
+								ASN:            65002,
+								Addr:           "192.0.2.2",
+								Password:       "nicepass",
+								Advertisements: []*frr.AdvertisementConfig{},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with missing password secret",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											PasswordSecret: v1.SecretReference{
+												Name:      "secret1",
+												Namespace: "frr-k8s-system",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to get the password for neighbor 65002@192.0.2.2: secret secret1 not found"),
+		},
+		{
+			name: "Neighbor with password secret of the wrong type",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											PasswordSecret: v1.SecretReference{
+												Name:      "secret1",
+												Namespace: "frr-k8s-system",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			secrets: map[string]v1.Secret{
+				"secret1": {
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret1",
+						Namespace: "frr-k8s-system",
+					},
+					Type: v1.SecretTypeOpaque,
+					Data: map[string][]byte{
+						"password": []byte("nicepass"),
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to get the password for neighbor 65002@192.0.2.2: secret type mismatch on frr-k8s-system/secret1, type kubernetes.io/basic-auth is expected"),
+		},
+		{
+			name: "Neighbor with password secret without the password",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											PasswordSecret: v1.SecretReference{
+												Name:      "secret1",
+												Namespace: "frr-k8s-system",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			secrets: map[string]v1.Secret{
+				"secret1": {
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret1",
+						Namespace: "frr-k8s-system",
+					},
+					Type: v1.SecretTypeBasicAuth,
+					Data: map[string][]byte{
+						"username": []byte("user"),
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to get the password for neighbor 65002@192.0.2.2: password not specified in secret frr-k8s-system/secret1"),
+		},
+		{
+			name: "Neighbor with timers and bfd profile",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:           65002,
+											Address:       "192.0.2.2",
+											HoldTime:      metav1.Duration{Duration: 90 * time.Second},
+											KeepaliveTime: metav1.Duration{Duration: 30 * time.Second},
+											BFDProfile:    "fast",
+										},
+									},
+								},
+							},
+							BFDProfiles: []v1beta1.BFDProfile{
+								{
+									Name:             "fast",
+									ReceiveInterval:  100,
+									DetectMultiplier: 5,
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65001,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65002@192.0.2.2",
+								ASN:            65002,
+								Addr:           "192.0.2.2",
+								HoldTime:       90,
+								KeepaliveTime:  30,
+								BFDProfile:     "fast",
+								Advertisements: []*frr.AdvertisementConfig{},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{
+					{
+						Name:             "fast",
+						ReceiveInterval:  &receiveInterval,
+						DetectMultiplier: &detectMultiplier,
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with keepalive time only",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:           65002,
+											Address:       "192.0.2.2",
+											KeepaliveTime: metav1.Duration{Duration: 20 * time.Second},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65001,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65002@192.0.2.2",
+								ASN:            65002,
+								Addr:           "192.0.2.2",
+								HoldTime:       60,
+								KeepaliveTime:  20,
+								Advertisements: []*frr.AdvertisementConfig{},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with hold time lower than 3s",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:      65002,
+											Address:  "192.0.2.2",
+											HoldTime: metav1.Duration{Duration: 2 * time.Second},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid timers for neighbor 65002@192.0.2.2: hold time 2s must be at least 3s"),
+		},
+		{
+			name: "Neighbor with keepalive time greater than hold time",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:           65002,
+											Address:       "192.0.2.2",
+											HoldTime:      metav1.Duration{Duration: 30 * time.Second},
+											KeepaliveTime: metav1.Duration{Duration: 40 * time.Second},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid timers for neighbor 65002@192.0.2.2: keepalive time 40s must be lower than the hold time 30s"),
+		},
+		{
+			name: "Neighbor with sub-second keepalive time",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:           65002,
+											Address:       "192.0.2.2",
+											KeepaliveTime: metav1.Duration{Duration: 500 * time.Millisecond},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid timers for neighbor 65002@192.0.2.2: keepalive time 500ms must be a whole number of seconds"),
+		},
+		{
+			name: "Neighbor with hold time not in whole seconds",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:      65002,
+											Address:  "192.0.2.2",
+											HoldTime: metav1.Duration{Duration: 90500 * time.Millisecond},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid timers for neighbor 65002@192.0.2.2: hold time 1m30.5s must be a whole number of seconds"),
+		},
+		{
+			name: "Neighbor referencing a missing bfd profile",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:        65002,
+											Address:    "192.0.2.2",
+											BFDProfile: "fast",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("neighbor 65002@192.0.2.2 references bfd profile fast which does not exist"),
+		},
+		{
+			name: "Multiple configs, bfd profiles with the same name and different settings",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "a",
+						Namespace: "default",
+					},
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:        65002,
+											Address:    "192.0.2.2",
+											BFDProfile: "fast",
+										},
+									},
+								},
+							},
+							BFDProfiles: []v1beta1.BFDProfile{
+								{
+									Name:             "fast",
+									ReceiveInterval:  100,
+									DetectMultiplier: 5,
+								},
+							},
+						},
+					},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "b",
+						Namespace: "default",
+					},
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:        65002,
+											Address:    "192.0.2.2",
+											BFDProfile: "fast",
+										},
+									},
+								},
+							},
+							BFDProfiles: []v1beta1.BFDProfile{
+								{
+									Name:            "fast",
+									ReceiveInterval: 200,
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("multiple bfd profiles named fast with different settings"),
+		},
+		{
+			name: "Neighbor with local pref and communities",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												PrefixesWithLocalPref: []v1beta1.LocalPrefPrefixes{
+													{
+														Prefixes:  []string{"192.0.2.0/24", "2001:db8::/64"},
+														LocalPref: 200,
+													},
+												},
+												PrefixesWithCommunity: []v1beta1.CommunityPrefixes{
+													{
+														Prefixes:  []string{"192.0.2.0/24"},
+														Community: "65040:100",
+													},
+													{
+														Prefixes:  []string{"192.0.2.0/24", "192.0.3.0/24"},
+														Community: "no-export",
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Name:     "65041@192.0.2.21",
+								ASN:      65041,
+								Addr:     "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily:    ipfamily.IPv4,
+										Prefix:      "192.0.2.0/24",
+										LocalPref:   200,
+										Communities: []string{"65040:100", "no-export"},
+									},
+									{
+										IPFamily:    ipfamily.IPv4,
+										Prefix:      "192.0.3.0/24",
+										Communities: []string{"no-export"},
+									},
+									{
+										IPFamily:  ipfamily.IPv6,
+										Prefix:    "2001:db8::/64",
+										LocalPref: 200,
+									},
+								},
+								HasV4Advertisements: true,
+								HasV6Advertisements: true,
+							},
+						},
+						IPV4Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+						IPV6Prefixes: []string{"2001:db8::/64"},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with community associated to a non allowed prefix",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.2.0/24"},
+												},
+												PrefixesWithCommunity: []v1beta1.CommunityPrefixes{
+													{
+														Prefixes:  []string{"192.0.3.0/24"},
+														Community: "65040:100",
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid advertisements for neighbor 65041@192.0.2.21: community 65040:100 associated to prefix 192.0.3.0/24, which is not allowed"),
+		},
+		{
+			name: "Neighbor with local pref associated to a non allowed prefix",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.2.0/24"},
+												},
+												PrefixesWithLocalPref: []v1beta1.LocalPrefPrefixes{
+													{
+														Prefixes:  []string{"192.0.3.0/24"},
+														LocalPref: 200,
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid advertisements for neighbor 65041@192.0.2.21: local pref 200 associated to prefix 192.0.3.0/24, which is not allowed"),
+		},
+		{
+			name: "Neighbor with invalid community",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												PrefixesWithCommunity: []v1beta1.CommunityPrefixes{
+													{
+														Prefixes:  []string{"192.0.2.0/24"},
+														Community: "65040:70000",
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid advertisements for neighbor 65041@192.0.2.21: invalid community 65040:70000, 70000 is not a 16 bits value"),
+		},
+		{
+			name: "Neighbor with multiple local prefs for the same prefix",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												PrefixesWithLocalPref: []v1beta1.LocalPrefPrefixes{
+													{
+														Prefixes:  []string{"192.0.2.0/24"},
+														LocalPref: 200,
+													},
+													{
+														Prefixes:  []string{"192.0.2.0/24"},
+														LocalPref: 300,
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid advertisements for neighbor 65041@192.0.2.21: multiple local prefs (200 != 300) specified for prefix 192.0.2.0/24"),
+		},
+		{
+			name: "Neighbor with filtered prefixes to receive",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToReceive: v1beta1.Receive{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"0.0.0.0/0", "2001:db8::/64", "192.0.4.0/24"},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65041@192.0.2.21",
+								ASN:            65041,
+								Addr:           "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{},
+								Incoming: frr.AllowedIn{
+									PrefixesV4: []frr.IncomingFilter{
+										{IPFamily: ipfamily.IPv4, Prefix: "0.0.0.0/0"},
+										{IPFamily: ipfamily.IPv4, Prefix: "192.0.4.0/24"},
+									},
+									PrefixesV6: []frr.IncomingFilter{
+										{IPFamily: ipfamily.IPv6, Prefix: "2001:db8::/64"},
+									},
+								},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor receiving all the prefixes",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToReceive: v1beta1.Receive{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65041@192.0.2.21",
+								ASN:            65041,
+								Addr:           "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{},
+								Incoming: frr.AllowedIn{
+									All: true,
+								},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with invalid prefix to receive",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToReceive: v1beta1.Receive{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.4.0"},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid prefixes to receive for neighbor 65041@192.0.2.21: invalid prefix 192.0.4.0: invalid CIDR address: 192.0.4.0"),
+		},
+		{
+			name: "Multiple configs, same neighbor with different prefixes to receive",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToReceive: v1beta1.Receive{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.5.0/24", "192.0.4.0/24"},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToReceive: v1beta1.Receive{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.4.0/24", "2001:db8::/64"},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65041@192.0.2.21",
+								ASN:            65041,
+								Addr:           "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{},
+								Incoming: frr.AllowedIn{
+									PrefixesV4: []frr.IncomingFilter{
+										{IPFamily: ipfamily.IPv4, Prefix: "192.0.4.0/24"},
+										{IPFamily: ipfamily.IPv4, Prefix: "192.0.5.0/24"},
+									},
+									PrefixesV6: []frr.IncomingFilter{
+										{IPFamily: ipfamily.IPv6, Prefix: "2001:db8::/64"},
+									},
+								},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with prefix selectors to receive",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToReceive: v1beta1.Receive{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.4.0/24"},
+													PrefixSelectors: []v1beta1.PrefixSelector{
+														{Prefix: "10.0.0.0/8", GE: 32},
+														{Prefix: "2001:db8::/32", GE: 48, LE: 64},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65041@192.0.2.21",
+								ASN:            65041,
+								Addr:           "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{},
+								Incoming: frr.AllowedIn{
+									PrefixesV4: []frr.IncomingFilter{
+										{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8", GE: 32},
+										{IPFamily: ipfamily.IPv4, Prefix: "192.0.4.0/24"},
+									},
+									PrefixesV6: []frr.IncomingFilter{
+										{IPFamily: ipfamily.IPv6, Prefix: "2001:db8::/32", GE: 48, LE: 64},
+									},
+								},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with prefix selectors to advertise",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.2.0/24"},
+													PrefixSelectors: []v1beta1.PrefixSelector{
+														{Prefix: "192.0.0.0/16", LE: 24},
+														{Prefix: "2001:db8::/32", GE: 64},
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24", "192.1.0.0/24", "192.0.4.0/28", "2001:db8::/64", "2001:db8::/32"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Name:     "65041@192.0.2.21",
+								ASN:      65041,
+								Addr:     "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.2.0/24",
+									},
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.3.0/24",
+									},
+									{
+										IPFamily: ipfamily.IPv6,
+										Prefix:   "2001:db8::/64",
+									},
+								},
+								HasV4Advertisements: true,
+								HasV6Advertisements: true,
+							},
+						},
+						IPV4Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24", "192.0.4.0/28", "192.1.0.0/24"},
+						IPV6Prefixes: []string{"2001:db8::/32", "2001:db8::/64"},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with invalid prefix selector",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToReceive: v1beta1.Receive{
+												Allowed: v1beta1.AllowedPrefixes{
+													PrefixSelectors: []v1beta1.PrefixSelector{
+														{Prefix: "10.0.0.0/8", GE: 8},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid prefixes to receive for neighbor 65041@192.0.2.21: invalid selector for 10.0.0.0/8, ge 8 must be greater than the prefix length"),
+		},
+		{
+			name: "Neighbor with large and extended communities",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												PrefixesWithCommunity: []v1beta1.CommunityPrefixes{
+													{
+														Prefixes:  []string{"192.0.2.0/24"},
+														Community: "soo:192.0.2.1:10",
+													},
+													{
+														Prefixes:  []string{"192.0.2.0/24"},
+														Community: "large:4200000000:1:2",
+													},
+													{
+														Prefixes:  []string{"192.0.2.0/24"},
+														Community: "65040:100",
+													},
+													{
+														Prefixes:  []string{"192.0.2.0/24"},
+														Community: "rt:65040:100",
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Name:     "65041@192.0.2.21",
+								ASN:      65041,
+								Addr:     "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily:            ipfamily.IPv4,
+										Prefix:              "192.0.2.0/24",
+										Communities:         []string{"65040:100"},
+										LargeCommunities:    []string{"4200000000:1:2"},
+										ExtendedCommunities: []string{"rt:65040:100", "soo:192.0.2.1:10"},
+									},
+								},
+								HasV4Advertisements: true,
+							},
+						},
+						IPV4Prefixes: []string{"192.0.2.0/24"},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with invalid large community",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												PrefixesWithCommunity: []v1beta1.CommunityPrefixes{
+													{
+														Prefixes:  []string{"192.0.2.0/24"},
+														Community: "large:65040:100",
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid advertisements for neighbor 65041@192.0.2.21: invalid large community large:65040:100, must be in the large:global:local1:local2 form"),
+		},
+		{
+			name: "Neighbor with as path prepend",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												ASPathPrepend: v1beta1.ASPathPrepend{
+													Times: 2,
+												},
+												PrefixesWithASPathPrepend: []v1beta1.ASPathPrependPrefixes{
+													{
+														Prefixes: []string{"192.0.3.0/24"},
+														ASPathPrepend: v1beta1.ASPathPrepend{
+															ASN:   65100,
+															Times: 3,
+														},
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Name:     "65041@192.0.2.21",
+								ASN:      65041,
+								Addr:     "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily:      ipfamily.IPv4,
+										Prefix:        "192.0.2.0/24",
+										ASPathPrepend: frr.ASPathPrepend{ASN: 65040, Times: 2},
+									},
+									{
+										IPFamily:      ipfamily.IPv4,
+										Prefix:        "192.0.3.0/24",
+										ASPathPrepend: frr.ASPathPrepend{ASN: 65100, Times: 3},
+									},
+								},
+								HasV4Advertisements: true,
+							},
+						},
+						IPV4Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with as path prepend associated to a non allowed prefix",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.2.0/24"},
+												},
+												PrefixesWithASPathPrepend: []v1beta1.ASPathPrependPrefixes{
+													{
+														Prefixes: []string{"192.0.3.0/24"},
+														ASPathPrepend: v1beta1.ASPathPrepend{
+															Times: 3,
+														},
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid advertisements for neighbor 65041@192.0.2.21: as path prepend associated to prefix 192.0.3.0/24, which is not allowed"),
+		},
+		{
+			name: "Neighbor with too many as path prepends",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												ASPathPrepend: v1beta1.ASPathPrepend{
+													Times: 11,
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid advertisements for neighbor 65041@192.0.2.21: invalid as path prepend times 11, must be between 0 and 10"),
+		},
+		{
+			name: "Multiple configs, same prefix with different as path prepends",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												ASPathPrepend: v1beta1.ASPathPrepend{
+													Times: 2,
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												ASPathPrepend: v1beta1.ASPathPrepend{
+													Times: 1,
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to merge configuration /: failed to merge advertisements for neighbor 192.0.2.21: multiple as path prepends (65040 65040 != 65040) specified for prefix 192.0.2.0/24"),
+		},
+		{
+			name: "Neighbor with med and origin",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												MED:    &med,
+												Origin: "igp",
+												PrefixesWithMED: []v1beta1.MEDPrefixes{
+													{
+														Prefixes: []string{"192.0.3.0/24"},
+														MED:      200,
+													},
+												},
+												PrefixesWithOrigin: []v1beta1.OriginPrefixes{
+													{
+														Prefixes: []string{"192.0.3.0/24"},
+														Origin:   "incomplete",
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Name:     "65041@192.0.2.21",
+								ASN:      65041,
+								Addr:     "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.2.0/24",
+										MED:      &med,
+										Origin:   "igp",
+									},
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.3.0/24",
+										MED:      &prefixMED,
+										Origin:   "incomplete",
+									},
+								},
+								HasV4Advertisements: true,
+							},
+						},
+						IPV4Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with med associated to a non allowed prefix",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.2.0/24"},
+												},
+												PrefixesWithMED: []v1beta1.MEDPrefixes{
+													{
+														Prefixes: []string{"192.0.3.0/24"},
+														MED:      200,
+													},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid advertisements for neighbor 65041@192.0.2.21: med 200 associated to prefix 192.0.3.0/24, which is not allowed"),
+		},
+		{
+			name: "Neighbor with invalid origin",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+												Origin: "bgp",
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid advertisements for neighbor 65041@192.0.2.21: invalid origin bgp, must be one of [egp igp incomplete]"),
+		},
+		{
+			name: "Unnumbered neighbor",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											DynamicASN: v1beta1.ExternalASNMode,
+											Interface:  "eth1",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:   ipfamily.DualStack,
+								Name:       "external@eth1",
+								DynamicASN: "external",
+								Iface:      "eth1",
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.2.0/24",
+									},
+									{
+										IPFamily: ipfamily.IPv6,
+										Prefix:   "2001:db8::/64",
+									},
+								},
+								HasV4Advertisements: true,
+								HasV6Advertisements: true,
+							},
+						},
+						IPV4Prefixes: []string{"192.0.2.0/24"},
+						IPV6Prefixes: []string{"2001:db8::/64"},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with both address and interface",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:       65041,
+											Address:   "192.0.2.21",
+											Interface: "eth1",
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("neighbor with address 192.0.2.21 and interface eth1, only one must be specified"),
+		},
+		{
+			name: "Neighbor with both asn and dynamic asn",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:        65041,
+											DynamicASN: v1beta1.InternalASNMode,
+											Interface:  "eth1",
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("neighbor eth1 has both asn 65041 and dynamicASN internal specified"),
+		},
+		{
+			name: "Neighbor without asn",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											Address: "192.0.2.21",
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("neighbor 192.0.2.21 has no asn or dynamicASN specified"),
+		},
+		{
+			name: "Unnumbered neighbor with invalid interface name",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											DynamicASN: v1beta1.ExternalASNMode,
+											Interface:  "a-very-long-interface-name",
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid interface name \"a-very-long-interface-name\" for neighbor"),
+		},
+		{
+			name: "Multihop unnumbered neighbor",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											DynamicASN:   v1beta1.ExternalASNMode,
+											Interface:    "eth1",
+											EBGPMultiHop: &multiHop,
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "2001:db8::/64"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("neighbor external@eth1 is an unnumbered neighbor and can't be multihop"),
+		},
+		{
+			name: "Dynamic neighbor",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									DynamicNeighbors: []v1beta1.DynamicNeighbor{
+										{
+											Name:         "vms",
+											ListenRanges: []string{"10.1.0.0/24", "10.2.0.1/24"},
+											ASN:          65050,
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:     ipfamily.IPv4,
+								Name:         "65050@vms",
+								ASN:          65050,
+								PeerGroup:    "vms",
+								ListenRanges: []string{"10.1.0.0/24", "10.2.0.0/24"},
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.2.0/24",
+									},
+								},
+								HasV4Advertisements: true,
+							},
+						},
+						IPV4Prefixes: []string{"192.0.2.0/24"},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Dynamic neighbors with the same listen range",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									DynamicNeighbors: []v1beta1.DynamicNeighbor{
+										{
+											Name:         "vms",
+											ListenRanges: []string{"10.1.0.0/24"},
+											ASN:          65050,
+										},
+										{
+											Name:         "routeservers",
+											ListenRanges: []string{"10.1.0.0/24"},
+											ASN:          65051,
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("listen range 10.1.0.0/24 associated to multiple dynamic neighbors (vms, routeservers)"),
+		},
+		{
+			name: "Dynamic neighbor with invalid name",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									DynamicNeighbors: []v1beta1.DynamicNeighbor{
+										{
+											Name:         "10.1.0.1",
+											ListenRanges: []string{"10.1.0.0/24"},
+											ASN:          65050,
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid name \"10.1.0.1\" for dynamic neighbor"),
+		},
+		{
+			name: "Dynamic neighbor without asn",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									DynamicNeighbors: []v1beta1.DynamicNeighbor{
+										{
+											Name:         "vms",
+											ListenRanges: []string{"10.1.0.0/24"},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("neighbor vms has no asn or dynamicASN specified"),
+		},
+		{
+			name: "Neighbors with template",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							NeighborTemplates: []v1beta1.NeighborTemplate{
+								{
+									Name:       "tors",
+									ASN:        65050,
+									HoldTime:   metav1.Duration{Duration: 90 * time.Second},
+									BFDProfile: "fast",
+									ToAdvertise: v1beta1.Advertise{
+										Allowed: v1beta1.AllowedPrefixes{
+											Mode: v1beta1.AllowAll,
+										},
+									},
+								},
+							},
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											Address:  "192.0.2.21",
+											Template: "tors",
+										},
+										{
+											Address:  "192.0.2.22",
+											Template: "tors",
+											ASN:      65051,
+											HoldTime: metav1.Duration{Duration: 180 * time.Second},
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.3.0/24"},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+								},
+							},
+							BFDProfiles: []v1beta1.BFDProfile{
+								{
+									Name: "fast",
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:      ipfamily.IPv4,
+								Name:          "65050@192.0.2.21",
+								ASN:           65050,
+								Addr:          "192.0.2.21",
+								HoldTime:      90,
+								KeepaliveTime: 30,
+								BFDProfile:    "fast",
+								Template: &frr.PeerGroupConfig{
+									Name:          "tors",
+									HoldTime:      90,
+									KeepaliveTime: 30,
+									BFDProfile:    "fast",
+								},
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.2.0/24",
+									},
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.3.0/24",
+									},
+								},
+								HasV4Advertisements: true,
+							},
+							{
+								IPFamily:      ipfamily.IPv4,
+								Name:          "65051@192.0.2.22",
+								ASN:           65051,
+								Addr:          "192.0.2.22",
+								HoldTime:      180,
+								KeepaliveTime: 60,
+								BFDProfile:    "fast",
+								Template: &frr.PeerGroupConfig{
+									Name:          "tors",
+									HoldTime:      90,
+									KeepaliveTime: 30,
+									BFDProfile:    "fast",
+								},
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.3.0/24",
+									},
+								},
+								HasV4Advertisements: true,
+							},
+						},
+						PeerGroups: []*frr.PeerGroupConfig{
+							{
+								Name:          "tors",
+								HoldTime:      90,
+								KeepaliveTime: 30,
+								BFDProfile:    "fast",
+							},
+						},
+						IPV4Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{
+					{
+						Name: "fast",
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbors overriding the template field by field",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							NeighborTemplates: []v1beta1.NeighborTemplate{
+								{
+									Name:         "tors",
+									ASN:          65050,
+									EBGPMultiHop: true,
+									ToAdvertise: v1beta1.Advertise{
+										Allowed: v1beta1.AllowedPrefixes{
+											Mode: v1beta1.AllowAll,
+										},
+										PrefixesWithCommunity: []v1beta1.CommunityPrefixes{
+											{
+												Prefixes:  []string{"192.0.2.0/24"},
+												Community: "no-export",
+											},
+										},
+									},
+								},
+							},
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											Address:  "192.0.2.21",
+											Template: "tors",
+										},
+										{
+											Address:      "192.0.2.22",
+											Template:     "tors",
+											EBGPMultiHop: &noMultiHop,
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Prefixes: []string{"192.0.2.0/24"},
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:     ipfamily.IPv4,
+								Name:         "65050@192.0.2.21",
+								ASN:          65050,
+								Addr:         "192.0.2.21",
+								EBGPMultiHop: true,
+								Template: &frr.PeerGroupConfig{
+									Name: "tors",
+								},
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily:    ipfamily.IPv4,
+										Prefix:      "192.0.2.0/24",
+										Communities: []string{"no-export"},
+									},
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.0.3.0/24",
+									},
+								},
+								HasV4Advertisements: true,
+							},
+							{
+								IPFamily: ipfamily.IPv4,
+								Name:     "65050@192.0.2.22",
+								ASN:      65050,
+								Addr:     "192.0.2.22",
+								Template: &frr.PeerGroupConfig{
+									Name: "tors",
+								},
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily:    ipfamily.IPv4,
+										Prefix:      "192.0.2.0/24",
+										Communities: []string{"no-export"},
+									},
+								},
+								HasV4Advertisements: true,
+							},
+						},
+						PeerGroups: []*frr.PeerGroupConfig{
+							{
+								Name: "tors",
+							},
+						},
+						IPV4Prefixes: []string{"192.0.2.0/24", "192.0.3.0/24"},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor referencing a non existing template",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											Address:  "192.0.2.21",
+											Template: "tors",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("neighbor 192.0.2.21 references template tors which does not exist"),
+		},
+		{
+			name: "Template clashing with a dynamic neighbor",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							NeighborTemplates: []v1beta1.NeighborTemplate{
+								{
+									Name: "vms",
+									ASN:  65050,
+								},
+							},
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											Address:  "192.0.2.21",
+											Template: "vms",
+										},
+									},
+									DynamicNeighbors: []v1beta1.DynamicNeighbor{
+										{
+											Name:         "vms",
+											ListenRanges: []string{"10.1.0.0/24"},
+											ASN:          65050,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("neighbor template vms clashes with neighbor 65050@vms"),
+		},
+		{
+			name: "Multiple configs, same template with different settings",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							NeighborTemplates: []v1beta1.NeighborTemplate{
+								{
+									Name:     "tors",
+									ASN:      65050,
+									HoldTime: metav1.Duration{Duration: 90 * time.Second},
+								},
+							},
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											Address:  "192.0.2.21",
+											Template: "tors",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							NeighborTemplates: []v1beta1.NeighborTemplate{
+								{
+									Name:     "tors",
+									ASN:      65050,
+									HoldTime: metav1.Duration{Duration: 180 * time.Second},
+								},
+							},
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											Address:  "192.0.2.22",
+											Template: "tors",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to merge configuration /: multiple neighbor templates named tors with different settings"),
+		},
+		{
+			name: "Router with graceful restart",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									GracefulRestart: v1beta1.GracefulRestart{
+										Mode:          v1beta1.GracefulRestartRestart,
+										RestartTime:   metav1.Duration{Duration: 4 * time.Minute},
+										StalePathTime: metav1.Duration{Duration: 6 * time.Minute},
+									},
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:                 65041,
+											Address:             "192.0.2.21",
+											GracefulRestartMode: v1beta1.GracefulRestartHelper,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						GracefulRestart: frr.GracefulRestartConfig{
+							Mode:          "restart",
+							RestartTime:   240,
+							StalePathTime: 360,
+						},
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:        ipfamily.IPv4,
+								Name:            "65041@192.0.2.21",
+								ASN:             65041,
+								Addr:            "192.0.2.21",
+								GracefulRestart: "helper",
+								Advertisements:  []*frr.AdvertisementConfig{},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Router with invalid graceful restart time",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									GracefulRestart: v1beta1.GracefulRestart{
+										RestartTime: metav1.Duration{Duration: 2 * time.Hour},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid graceful restart for router 65040: invalid restart time 2h0m0s, must be between 0s and 4095s"),
+		},
+		{
+			name: "Multiple configs, different graceful restart modes",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									GracefulRestart: v1beta1.GracefulRestart{
+										Mode: v1beta1.GracefulRestartRestart,
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									GracefulRestart: v1beta1.GracefulRestart{
+										Mode:        v1beta1.GracefulRestartDisabled,
+										RestartTime: metav1.Duration{Duration: 4 * time.Minute},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to merge configuration /: different graceful restart modes (restart != disabled) specified for same vrf: "),
+		},
+		{
+			name: "Router leaking routes from other vrfs",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									VRF: "red",
+									Imports: []v1beta1.Import{
+										{
+											VRF: "default",
+											PrefixSelectors: []v1beta1.PrefixSelector{
+												{
+													Prefix: "0.0.0.0/0",
+												},
+											},
+										},
+										{
+											VRF: "blue",
+										},
+									},
+									RouteTargets: v1beta1.RouteTargets{
+										RD:     "65040:1",
+										Import: []string{"65040:200"},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									VRF: "red",
+									Imports: []v1beta1.Import{
+										{
+											VRF: "default",
+											PrefixSelectors: []v1beta1.PrefixSelector{
+												{
+													Prefix: "::/0",
+												},
+											},
+										},
+									},
+									RouteTargets: v1beta1.RouteTargets{
+										Import: []string{"65040:100"},
+										Export: []string{"65040:100"},
+										RD:     "65040:1",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN:     65040,
+						VRF:       "red",
+						Neighbors: []*frr.NeighborConfig{},
+						Imports: []frr.VRFImport{
+							{
+								VRF: "blue",
+								Allowed: frr.AllowedIn{
+									All: true,
+								},
+							},
+							{
+								VRF: "default",
+								Allowed: frr.AllowedIn{
+									PrefixesV4: []frr.IncomingFilter{
+										{
+											IPFamily: ipfamily.IPv4,
+											Prefix:   "0.0.0.0/0",
+										},
+									},
+									PrefixesV6: []frr.IncomingFilter{
+										{
+											IPFamily: ipfamily.IPv6,
+											Prefix:   "::/0",
+										},
+									},
+								},
+							},
+						},
+						RouteTargets: frr.RouteTargetsConfig{
+							RD:     "65040:1",
+							Import: []string{"65040:100", "65040:200"},
+							Export: []string{"65040:100"},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Router importing from its own vrf",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Imports: []v1beta1.Import{
+										{
+											VRF: "default",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid imports for router 65040: vrf default can't import from itself"),
+		},
+		{
+			name: "Router exporting without route distinguisher",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									VRF: "red",
+									RouteTargets: v1beta1.RouteTargets{
+										Export: []string{"65040:100"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid route targets for router 65040: a route distinguisher is required to export the routes"),
+		},
+		{
+			name: "Multiple configs, different route distinguishers",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									VRF: "red",
+									RouteTargets: v1beta1.RouteTargets{
+										RD: "65040:1",
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									VRF: "red",
+									RouteTargets: v1beta1.RouteTargets{
+										RD: "65040:2",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to merge configuration /: different route distinguishers (65040:1 != 65040:2) specified for same vrf: red"),
+		},
+		{
+			name: "Routers with evpn",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65041,
+											Address: "192.0.2.21",
+										},
+									},
+									EVPN: v1beta1.EVPN{
+										AdvertiseAllVNI: true,
+										VNIs: []v1beta1.VNI{
+											{
+												VNI:       200,
+												RD:        "65040:200",
+												ImportRTs: []string{"65040:200"},
+											},
+											{
+												VNI:       100,
+												ExportRTs: []string{"65040:100"},
+											},
+										},
+									},
+								},
+								{
+									ASN: 65040,
+									VRF: "red",
+									EVPN: v1beta1.EVPN{
+										AdvertiseIPv4Unicast: true,
+										RD:                   "65040:1",
+										ImportRTs:            []string{"65040:1"},
+										ExportRTs:            []string{"65040:1"},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									EVPN: v1beta1.EVPN{
+										VNIs: []v1beta1.VNI{
+											{
+												VNI:       200,
+												ImportRTs: []string{"65040:201"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65040,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65041@192.0.2.21",
+								ASN:            65041,
+								Addr:           "192.0.2.21",
+								Advertisements: []*frr.AdvertisementConfig{},
+							},
+						},
+						EVPN: frr.EVPNConfig{
+							AdvertiseAllVNI: true,
+							VNIs: []frr.VNIConfig{
+								{
+									VNI:       100,
+									ExportRTs: []string{"65040:100"},
+								},
+								{
+									VNI:       200,
+									RD:        "65040:200",
+									ImportRTs: []string{"65040:200", "65040:201"},
+								},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+					{
+						MyASN:     65040,
+						VRF:       "red",
+						Neighbors: []*frr.NeighborConfig{},
+						EVPN: frr.EVPNConfig{
+							AdvertiseIPv4Unicast: true,
+							RD:                   "65040:1",
+							ImportRTs:            []string{"65040:1"},
+							ExportRTs:            []string{"65040:1"},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Vrf router advertising vnis",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									VRF: "red",
+									EVPN: v1beta1.EVPN{
+										AdvertiseAllVNI: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid evpn for router 65040: the vnis can be advertised only by the router of the default vrf"),
+		},
+		{
+			name: "Evpn with invalid route target",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									EVPN: v1beta1.EVPN{
+										VNIs: []v1beta1.VNI{
+											{
+												VNI:       100,
+												ImportRTs: []string{"foo:100"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid evpn for router 65040: invalid vni 100: invalid route target foo:100, foo is neither an AS number nor an IPv4 address"),
+		},
+		{
+			name: "Multiple configs, different route distinguishers for the same vni",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									EVPN: v1beta1.EVPN{
+										VNIs: []v1beta1.VNI{
+											{
+												VNI: 100,
+												RD:  "65040:100",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65040,
+									EVPN: v1beta1.EVPN{
+										VNIs: []v1beta1.VNI{
+											{
+												VNI: 100,
+												RD:  "65040:101",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to merge configuration /: different route distinguishers (65040:100 != 65040:101) for vni 100 specified in evpn for same vrf: "),
+		},
+		{
+			name: "Neighbor with update source address",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											Port:    179,
+											UpdateSource: v1beta1.UpdateSource{
+												Address: "192.0.2.10",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65001,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65002@192.0.2.2",
+								ASN:            65002,
+								SrcAddr:        "192.0.2.10",
+								Addr:           "192.0.2.2",
+								Port:           179,
+								Advertisements: []*frr.AdvertisementConfig{},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with update source address of a different family",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											Port:    179,
+											UpdateSource: v1beta1.UpdateSource{
+												Address: "2001:db8::10",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid update source for neighbor 65002@192.0.2.2: address 2001:db8::10 does not match the ip family of the neighbor (ipv4)"),
+		},
+		{
+			name: "Neighbor with update source interface",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											Port:    179,
+											UpdateSource: v1beta1.UpdateSource{
+												Interface: "eth1",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65001,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65002@192.0.2.2",
+								ASN:            65002,
+								SrcAddr:        "eth1",
+								Addr:           "192.0.2.2",
+								Port:           179,
+								Advertisements: []*frr.AdvertisementConfig{},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with update source from dual stack node annotation",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "2001:db8::2",
+											Port:    179,
+											UpdateSource: v1beta1.UpdateSource{
+												NodeAnnotation: "frrk8s.metallb.io/source",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			annotations: map[string]string{
+				"frrk8s.metallb.io/source": "192.0.2.10,2001:db8::10",
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65001,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv6,
+								Name:           "65002@2001:db8::2",
+								ASN:            65002,
+								SrcAddr:        "2001:db8::10",
+								Addr:           "2001:db8::2",
+								Port:           179,
+								Advertisements: []*frr.AdvertisementConfig{},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with update source from missing node annotation",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											Port:    179,
+											UpdateSource: v1beta1.UpdateSource{
+												NodeAnnotation: "frrk8s.metallb.io/source",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			annotations: map[string]string{
+				"other": "192.0.2.10",
+			},
+			expected: nil,
+			err:      errors.New("invalid update source for neighbor 65002@192.0.2.2: node annotation frrk8s.metallb.io/source not found"),
+		},
+		{
+			name: "Neighbor with both update source address and interface",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											Port:    179,
+											UpdateSource: v1beta1.UpdateSource{
+												Address:   "192.0.2.10",
+												Interface: "eth1",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid update source for neighbor 65002@192.0.2.2: only one of address, interface and node annotation must be specified"),
+		},
+		{
+			name: "Neighbor with maximum prefixes",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											Port:    179,
+											MaximumPrefixes: []v1beta1.MaximumPrefix{
+												{
+													IPFamily:     "ipv4",
+													Limit:        100,
+													RestartAfter: metav1.Duration{Duration: 5 * time.Minute},
+												},
+												{
+													IPFamily:    "ipv6",
+													Limit:       50,
+													WarningOnly: true,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65001,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65002@192.0.2.2",
+								ASN:            65002,
+								Addr:           "192.0.2.2",
+								Port:           179,
+								Advertisements: []*frr.AdvertisementConfig{},
+								MaximumPrefixV4: &frr.MaximumPrefixConfig{
+									Limit:   100,
+									Restart: 5,
+								},
+								MaximumPrefixV6: &frr.MaximumPrefixConfig{
+									Limit:       50,
+									WarningOnly: true,
+								},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Neighbor with maximum prefixes both warning only and restarting",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											Port:    179,
+											MaximumPrefixes: []v1beta1.MaximumPrefix{
+												{
+													IPFamily:     "ipv4",
+													Limit:        100,
+													WarningOnly:  true,
+													RestartAfter: metav1.Duration{Duration: 5 * time.Minute},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid maximum prefixes for neighbor 65002@192.0.2.2: warning only and restart after can't be both specified for ipv4"),
+		},
+		{
+			name: "Neighbor with maximum prefixes restarting after seconds",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											Port:    179,
+											MaximumPrefixes: []v1beta1.MaximumPrefix{
+												{
+													IPFamily:     "ipv4",
+													Limit:        100,
+													RestartAfter: metav1.Duration{Duration: 90 * time.Second},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid maximum prefixes for neighbor 65002@192.0.2.2: restart after 1m30s for ipv4 must be a positive number of minutes"),
+		},
+		{
+			name: "Neighbor with multiple maximum prefixes for the same family",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											Port:    179,
+											MaximumPrefixes: []v1beta1.MaximumPrefix{
+												{
+													IPFamily: "ipv6",
+													Limit:    100,
+												},
+												{
+													IPFamily: "ipv6",
+													Limit:    200,
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid maximum prefixes for neighbor 65002@192.0.2.2: multiple limits specified for ipv6"),
+		},
+		{
+			name: "Router with multipath",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Multipath: v1beta1.Multipath{
+										MaximumPathsEBGP:     4,
+										MaximumPathsIBGP:     2,
+										ASPathMultipathRelax: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN:        65001,
+						Neighbors:    []*frr.NeighborConfig{},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+						Multipath: frr.MultipathConfig{
+							MaximumPathsEBGP:     4,
+							MaximumPathsIBGP:     2,
+							ASPathMultipathRelax: true,
+						},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Router with too many multipaths",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Multipath: v1beta1.Multipath{
+										MaximumPathsEBGP: 65,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid multipath for router 65001: invalid maximum ebgp paths 65, must be at most 64"),
+		},
+		{
+			name: "Multiple configs with multipath",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Multipath: v1beta1.Multipath{
+										MaximumPathsEBGP: 4,
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Multipath: v1beta1.Multipath{
+										MaximumPathsIBGP:     2,
+										ASPathMultipathRelax: true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN:        65001,
+						Neighbors:    []*frr.NeighborConfig{},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+						Multipath: frr.MultipathConfig{
+							MaximumPathsEBGP:     4,
+							MaximumPathsIBGP:     2,
+							ASPathMultipathRelax: true,
+						},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Multiple configs with different multipath",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Multipath: v1beta1.Multipath{
+										MaximumPathsEBGP: 4,
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Multipath: v1beta1.Multipath{
+										MaximumPathsEBGP: 8,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to merge configuration /: different maximum ebgp paths (4 != 8) specified for same vrf: "),
+		},
+		{
+			name: "Router with aggregates",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											Port:    179,
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+											},
+										},
+									},
+									Prefixes: []string{"192.168.1.1/32", "192.168.1.2/32", "2001:db8::1/128"},
+									Aggregates: []v1beta1.Aggregate{
+										{
+											Prefix:      "192.168.0.0/16",
+											SummaryOnly: true,
+										},
+										{
+											Prefix: "2001:db8::/32",
+											ASSet:  true,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65001,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Name:     "65002@192.0.2.2",
+								ASN:      65002,
+								Addr:     "192.0.2.2",
+								Port:     179,
+								Advertisements: []*frr.AdvertisementConfig{
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.168.0.0/16",
+									},
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.168.1.1/32",
+									},
+									{
+										IPFamily: ipfamily.IPv4,
+										Prefix:   "192.168.1.2/32",
+									},
+									{
+										IPFamily: ipfamily.IPv6,
+										Prefix:   "2001:db8::/32",
+									},
+									{
+										IPFamily: ipfamily.IPv6,
+										Prefix:   "2001:db8::1/128",
+									},
+								},
+								HasV4Advertisements: true,
+								HasV6Advertisements: true,
+							},
+						},
+						IPV4Prefixes: []string{"192.168.1.1/32", "192.168.1.2/32"},
+						IPV6Prefixes: []string{"2001:db8::1/128"},
+						AggregatesV4: []frr.AggregateConfig{
+							{
+								Prefix:      "192.168.0.0/16",
+								SummaryOnly: true,
+							},
+						},
+						AggregatesV6: []frr.AggregateConfig{
+							{
+								Prefix: "2001:db8::/32",
+								ASSet:  true,
+							},
+						},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Router with aggregate not covering any prefix",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN:      65001,
+									Prefixes: []string{"192.168.1.1/32"},
+									Aggregates: []v1beta1.Aggregate{
+										{
+											Prefix: "10.0.0.0/8",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid aggregates for router 65001: aggregate 10.0.0.0/8 does not cover any of the prefixes of the router"),
+		},
+		{
+			name: "Multiple configs with different options for the same aggregate",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN:      65001,
+									Prefixes: []string{"192.168.1.1/32"},
+									Aggregates: []v1beta1.Aggregate{
+										{
+											Prefix:      "192.168.0.0/16",
+											SummaryOnly: true,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN:      65001,
+									Prefixes: []string{"192.168.1.2/32"},
+									Aggregates: []v1beta1.Aggregate{
+										{
+											Prefix: "192.168.0.0/16",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to merge configuration /: different options for aggregate 192.168.0.0/16 specified for same vrf: "),
+		},
+		{
+			name: "Router with redistribution",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Neighbors: []v1beta1.Neighbor{
+										{
+											ASN:     65002,
+											Address: "192.0.2.2",
+											ToAdvertise: v1beta1.Advertise{
+												Allowed: v1beta1.AllowedPrefixes{
+													Mode: v1beta1.AllowAll,
+												},
+											},
+										},
+										{
+											ASN:     65003,
+											Address: "192.0.2.3",
+										},
+									},
+									Redistribute: []v1beta1.Redistribute{
+										{
+											Type: v1beta1.RedistributeStatic,
+										},
+										{
+											Type: v1beta1.RedistributeConnected,
+											PrefixSelectors: []v1beta1.PrefixSelector{
+												{Prefix: "10.0.0.0/8", GE: 24},
+												{Prefix: "2001:db8::/32"},
+											},
+											LocalPref:   200,
+											Origin:      "igp",
+											Communities: []string{"10:200", "rt:65000:100", "10:100", "large:123:456:7890"},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: &frr.Config{
+				Routers: []*frr.RouterConfig{
+					{
+						MyASN: 65001,
+						Neighbors: []*frr.NeighborConfig{
+							{
+								IPFamily:               ipfamily.IPv4,
+								Name:                   "65002@192.0.2.2",
+								ASN:                    65002,
+								Addr:                   "192.0.2.2",
+								Advertisements:         []*frr.AdvertisementConfig{},
+								AdvertiseRedistributed: true,
+							},
+							{
+								IPFamily:       ipfamily.IPv4,
+								Name:           "65003@192.0.2.3",
+								ASN:            65003,
+								Addr:           "192.0.2.3",
+								Advertisements: []*frr.AdvertisementConfig{},
+							},
+						},
+						IPV4Prefixes: []string{},
+						IPV6Prefixes: []string{},
+						Redistribute: []frr.RedistributeConfig{
+							{
+								Type: "connected",
+								Allowed: frr.AllowedIn{
+									PrefixesV4: []frr.IncomingFilter{
+										{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8", GE: 24},
+									},
+									PrefixesV6: []frr.IncomingFilter{
+										{IPFamily: ipfamily.IPv6, Prefix: "2001:db8::/32"},
+									},
+								},
+								LocalPref:           200,
+								Origin:              "igp",
+								Communities:         []string{"10:100", "10:200"},
+								LargeCommunities:    []string{"123:456:7890"},
+								ExtendedCommunities: []string{"rt:65000:100"},
+							},
+							{
+								Type:    "static",
+								Allowed: frr.AllowedIn{All: true},
+							},
+						},
+					},
+				},
+				BFDProfiles: []frr.BFDProfile{},
+			},
+			err: nil,
+		},
+		{
+			name: "Router with duplicate redistribution",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Redistribute: []v1beta1.Redistribute{
+										{
+											Type: v1beta1.RedistributeKernel,
+										},
+										{
+											Type:      v1beta1.RedistributeKernel,
+											LocalPref: 100,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid redistribution for router 65001: duplicate redistribution of kernel routes"),
+		},
+		{
+			name: "Router with invalid redistribution type",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Redistribute: []v1beta1.Redistribute{
+										{
+											Type: "ospf",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("invalid redistribution for router 65001: invalid type ospf, must be one of [connected kernel static]"),
+		},
+		{
+			name: "Multiple configs redistributing the same routes with different attributes",
+			fromK8s: []v1beta1.FRRConfiguration{
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Redistribute: []v1beta1.Redistribute{
+										{
+											Type:      v1beta1.RedistributeConnected,
+											LocalPref: 100,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					Spec: v1beta1.FRRConfigurationSpec{
+						BGP: v1beta1.BGPConfig{
+							Routers: []v1beta1.Router{
+								{
+									ASN: 65001,
+									Redistribute: []v1beta1.Redistribute{
+										{
+											Type:      v1beta1.RedistributeConnected,
+											LocalPref: 200,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: nil,
+			err:      errors.New("failed to merge configuration /: different attributes for the redistributed connected routes specified for same vrf: "),
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			frr, err := apiToFRR(test.fromK8s[0]) // TODO: pass the array when we start supporting merge
+			frr, err := apiToFRR(clusterResources{
+				FRRConfigs:      test.fromK8s,
+				PasswordSecrets: test.secrets,
+				NodeAnnotations: test.annotations,
+			})
 			if test.err != nil && err == nil {
 				t.Fatalf("expected error, got nil")
 			}
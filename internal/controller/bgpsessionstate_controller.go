@@ -0,0 +1,155 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	frrk8sv1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/internal/frr"
+)
+
+// BGPSessionStateReconciler periodically publishes the state of the BGP
+// sessions of the node as BGPSessionState objects.
+type BGPSessionStateReconciler struct {
+	client.Client
+	Scheme    *runtime.Scheme
+	Logger    log.Logger
+	NodeName  string
+	Namespace string
+	// BGPNeighbors returns the BGP neighbors of the node, grouped by vrf.
+	BGPNeighbors func() (map[string][]*frr.Neighbor, error)
+	// BFDPeers returns the BFD peers of the node, grouped by vrf.
+	BFDPeers func() (map[string][]frr.BFDPeer, error)
+	// ResyncPeriod is the interval between two consecutive collections
+	// of the state of the sessions.
+	ResyncPeriod time.Duration
+}
+
+// +kubebuilder:rbac:groups=frrk8s.metallb.io,resources=bgpsessionstates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=frrk8s.metallb.io,resources=bgpsessionstates/status,verbs=get;update;patch
+
+func (r *BGPSessionStateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	level.Info(r.Logger).Log("controller", "BGPSessionStateReconciler", "start reconcile", req.NamespacedName.String())
+	defer level.Info(r.Logger).Log("controller", "BGPSessionStateReconciler", "end reconcile", req.NamespacedName.String())
+
+	thisNode := &corev1.Node{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: r.NodeName}, thisNode)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	neighbors, err := r.BGPNeighbors()
+	if err != nil {
+		level.Error(r.Logger).Log("controller", "BGPSessionStateReconciler", "failed to fetch the bgp neighbors", req.NamespacedName.String(), "error", err)
+		return ctrl.Result{RequeueAfter: r.ResyncPeriod}, nil
+	}
+	bfdPeers, err := r.BFDPeers()
+	if err != nil {
+		level.Error(r.Logger).Log("controller", "BGPSessionStateReconciler", "failed to fetch the bfd peers", req.NamespacedName.String(), "error", err)
+		return ctrl.Result{RequeueAfter: r.ResyncPeriod}, nil
+	}
+	desired := bgpSessionStatesFor(r.NodeName, neighbors, bfdPeers)
+
+	existing := frrk8sv1beta1.BGPSessionStateList{}
+	err = r.Client.List(ctx, &existing, client.InNamespace(r.Namespace),
+		client.MatchingLabels{frrk8sv1beta1.BGPSessionStateNodeLabel: r.NodeName})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	errs := []error{}
+	for i := range existing.Items {
+		s := &existing.Items[i]
+		if _, ok := desired[s.Name]; ok {
+			continue
+		}
+		if err := r.Client.Delete(ctx, s); client.IgnoreNotFound(err) != nil {
+			errs = append(errs, fmt.Errorf("failed to delete %s: %w", s.Name, err))
+		}
+	}
+
+	for name, status := range desired {
+		if err := r.publishState(ctx, thisNode, name, status); err != nil {
+			errs = append(errs, fmt.Errorf("failed to publish %s: %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		level.Error(r.Logger).Log("controller", "BGPSessionStateReconciler", "failed to publish the session states", "error", utilerrors.NewAggregate(errs))
+		return ctrl.Result{}, utilerrors.NewAggregate(errs)
+	}
+	return ctrl.Result{RequeueAfter: r.ResyncPeriod}, nil
+}
+
+// publishState creates the BGPSessionState with the given name if it does
+// not exist, and updates its status.
+func (r *BGPSessionStateReconciler) publishState(ctx context.Context, node *corev1.Node, name string, status frrk8sv1beta1.BGPSessionStateStatus) error {
+	// The state is owned by the node, so that it gets deleted together with it.
+	state := &frrk8sv1beta1.BGPSessionState{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: r.Namespace}}
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, state, func() error {
+		if state.Labels == nil {
+			state.Labels = map[string]string{}
+		}
+		state.Labels[frrk8sv1beta1.BGPSessionStateNodeLabel] = r.NodeName
+		return controllerutil.SetOwnerReference(node, state, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(status, state.Status) {
+		return nil
+	}
+	state.Status = status
+	return r.Client.Status().Update(ctx, state)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *BGPSessionStateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	thisNode := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetName() == r.NodeName
+	})
+	thisNodeStates := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetNamespace() == r.Namespace && o.GetLabels()[frrk8sv1beta1.BGPSessionStateNodeLabel] == r.NodeName
+	})
+
+	// The node is watched only to trigger the first collection, the following
+	// ones are driven by the resync period.
+	nodeCreated := predicate.Funcs{
+		UpdateFunc: func(event.UpdateEvent) bool { return false },
+		DeleteFunc: func(event.DeleteEvent) bool { return false },
+	}
+
+	// All the sessions are collected at once, so every event is mapped to the
+	// same request. This also guarantees there is only one periodic resync.
+	enqueueNode := handler.EnqueueRequestsFromMapFunc(func(client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: r.NodeName}}}
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("bgpsessionstate").
+		Watches(&source.Kind{Type: &corev1.Node{}}, enqueueNode, builder.WithPredicates(thisNode, nodeCreated)).
+		Watches(&source.Kind{Type: &frrk8sv1beta1.BGPSessionState{}}, enqueueNode, builder.WithPredicates(thisNodeStates, predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
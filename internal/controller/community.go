@@ -0,0 +1,129 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// wellKnownCommunities are the names of the well known communities
+// (RFC1997 and following) that FRR accepts in place of their value.
+var wellKnownCommunities = sets.New(
+	"internet",
+	"local-AS",
+	"no-advertise",
+	"no-export",
+	"no-peer",
+	"blackhole",
+	"graceful-shutdown",
+	"accept-own",
+	"llgr-stale",
+	"no-llgr",
+)
+
+const (
+	largeCommunityPrefix = "large:"
+	// Route target and site of origin are the extended communities that
+	// can be set on the advertised prefixes.
+	routeTargetPrefix  = "rt:"
+	siteOfOriginPrefix = "soo:"
+)
+
+type communityType int
+
+const (
+	standardCommunity communityType = iota
+	largeCommunity
+	extendedCommunity
+)
+
+// parseCommunity validates the given community and returns its type,
+// together with the value to be rendered in the FRR configuration.
+// Large communities are in the large:global:local1:local2 form (RFC8092),
+// extended ones in the rt:value or soo:value form, and any other is
+// expected to be a standard community.
+func parseCommunity(c string) (communityType, string, error) {
+	switch {
+	case strings.HasPrefix(c, largeCommunityPrefix):
+		value := strings.TrimPrefix(c, largeCommunityPrefix)
+		if err := validateLargeCommunity(value); err != nil {
+			return 0, "", fmt.Errorf("invalid large community %s, %w", c, err)
+		}
+		return largeCommunity, value, nil
+	case strings.HasPrefix(c, routeTargetPrefix), strings.HasPrefix(c, siteOfOriginPrefix):
+		value := strings.SplitN(c, ":", 2)[1]
+		if err := validateExtendedCommunity(value); err != nil {
+			return 0, "", fmt.Errorf("invalid extended community %s, %w", c, err)
+		}
+		return extendedCommunity, c, nil
+	}
+	if err := validateCommunity(c); err != nil {
+		return 0, "", err
+	}
+	return standardCommunity, c, nil
+}
+
+// validateCommunity checks that the given community is either a well known
+// community name or in the AS:value form, with both the parts being 16 bits
+// values.
+func validateCommunity(c string) error {
+	if wellKnownCommunities.Has(c) {
+		return nil
+	}
+	parts := strings.Split(c, ":")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid community %s, must be either a well known community or in the AS:value form", c)
+	}
+	for _, p := range parts {
+		if _, err := strconv.ParseUint(p, 10, 16); err != nil {
+			return fmt.Errorf("invalid community %s, %s is not a 16 bits value", c, p)
+		}
+	}
+	return nil
+}
+
+// validateLargeCommunity checks that the given value is in the
+// global:local1:local2 form, with all the parts being 32 bits values.
+func validateLargeCommunity(value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return fmt.Errorf("must be in the large:global:local1:local2 form")
+	}
+	for _, p := range parts {
+		if _, err := strconv.ParseUint(p, 10, 32); err != nil {
+			return fmt.Errorf("%s is not a 32 bits value", p)
+		}
+	}
+	return nil
+}
+
+// validateExtendedCommunity checks that the given value is in one of
+// the forms of RFC4360 accepted by FRR: a 16 bits AS with a 32 bits value,
+// or a 32 bits AS or an IPv4 address with a 16 bits value.
+func validateExtendedCommunity(value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) != 2 {
+		return fmt.Errorf("must be in the AS:value or IP:value form")
+	}
+
+	valueBits := 16
+	if net.ParseIP(parts[0]).To4() == nil {
+		as, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil {
+			return fmt.Errorf("%s is neither an AS number nor an IPv4 address", parts[0])
+		}
+		if as <= math.MaxUint16 {
+			valueBits = 32
+		}
+	}
+	if _, err := strconv.ParseUint(parts[1], 10, valueBits); err != nil {
+		return fmt.Errorf("%s is not a %d bits value", parts[1], valueBits)
+	}
+	return nil
+}
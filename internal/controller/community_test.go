@@ -0,0 +1,49 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"testing"
+)
+
+func TestParseCommunity(t *testing.T) {
+	tests := []struct {
+		community     string
+		expectedType  communityType
+		expectedValue string
+		expectError   bool
+	}{
+		{community: "no-export", expectedType: standardCommunity, expectedValue: "no-export"},
+		{community: "65000:100", expectedType: standardCommunity, expectedValue: "65000:100"},
+		{community: "65536:100", expectError: true},
+		{community: "65000", expectError: true},
+		{community: "large:4200000000:1:2", expectedType: largeCommunity, expectedValue: "4200000000:1:2"},
+		{community: "large:65000:100", expectError: true},
+		{community: "large:4294967296:1:2", expectError: true},
+		{community: "rt:65000:4200000000", expectedType: extendedCommunity, expectedValue: "rt:65000:4200000000"},
+		{community: "rt:4200000000:100", expectedType: extendedCommunity, expectedValue: "rt:4200000000:100"},
+		{community: "rt:4200000000:65536", expectError: true},
+		{community: "soo:192.0.2.1:100", expectedType: extendedCommunity, expectedValue: "soo:192.0.2.1:100"},
+		{community: "soo:192.0.2.1:65536", expectError: true},
+		{community: "soo:2001:db8::1:100", expectError: true},
+		{community: "rt:65000", expectError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.community, func(t *testing.T) {
+			communityType, value, err := parseCommunity(test.community)
+			if test.expectError {
+				if err == nil {
+					t.Fatalf("expected error, got type %d value %s", communityType, value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %s", err)
+			}
+			if communityType != test.expectedType || value != test.expectedValue {
+				t.Fatalf("expected type %d value %s, got type %d value %s", test.expectedType, test.expectedValue, communityType, value)
+			}
+		})
+	}
+}
@@ -19,13 +19,20 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
+	"github.com/google/go-cmp/cmp"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -37,6 +44,7 @@ import (
 	"github.com/metallb/frrk8s/internal/frr"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -44,17 +52,22 @@ import (
 // http://onsi.github.io/ginkgo/ to learn more about Ginkgo.
 
 var (
-	cfg       *rest.Config
-	k8sClient client.Client
-	testEnv   *envtest.Environment
-	localFRR  fakeFRR
-	ctx       context.Context
-	cancel    context.CancelFunc
+	cfg             *rest.Config
+	k8sClient       client.Client
+	testEnv         *envtest.Environment
+	localFRR        fakeFRR
+	nodeStateEvents chan event.GenericEvent
+	bgpNeighbors    map[string][]*frr.Neighbor
+	ctx             context.Context
+	cancel          context.CancelFunc
 )
 
+const testNodeName = "testnode"
+
 type fakeFRR struct {
-	lastConfig *frr.Config
-	mustError  bool
+	lastConfig       *frr.Config
+	lastReloadResult string
+	mustError        bool
 }
 
 func (f *fakeFRR) ApplyConfig(config *frr.Config) error {
@@ -65,6 +78,10 @@ func (f *fakeFRR) ApplyConfig(config *frr.Config) error {
 	return nil
 }
 
+func (f *fakeFRR) ReloadStatus() frr.ReloadStatus {
+	return frr.ReloadStatus{Config: f.lastConfig, LastReloadResult: f.lastReloadResult}
+}
+
 func TestAPIs(t *testing.T) {
 	if testing.Short() {
 		t.Skip("skipping test in short mode.")
@@ -74,6 +91,55 @@ func TestAPIs(t *testing.T) {
 	RunSpecs(t, "Controller Suite")
 }
 
+func TestConfigsForNode(t *testing.T) {
+	configs := []frrk8sv1beta1.FRRConfiguration{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "all", Namespace: "default"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid", Namespace: "default"},
+			Spec: frrk8sv1beta1.FRRConfigurationSpec{
+				NodeSelector: metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "zone", Operator: "Between"},
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "matching", Namespace: "default"},
+			Spec: frrk8sv1beta1.FRRConfigurationSpec{
+				NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "a"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+			Spec: frrk8sv1beta1.FRRConfigurationSpec{
+				NodeSelector: metav1.LabelSelector{MatchLabels: map[string]string{"zone": "b"}},
+			},
+		},
+	}
+
+	selected, invalid := configsForNode(configs, map[string]string{"zone": "a"})
+	names := []string{}
+	for _, c := range selected {
+		names = append(names, c.Name)
+	}
+	if !cmp.Equal(names, []string{"all", "matching"}) {
+		t.Fatalf("unexpected selected configurations: %v", names)
+	}
+	if len(invalid) != 1 {
+		t.Fatalf("expected one invalid configuration, got %d", len(invalid))
+	}
+	err, ok := invalid[types.NamespacedName{Namespace: "default", Name: "invalid"}]
+	if !ok {
+		t.Fatalf("expected default/invalid to be invalid, got %v", invalid)
+	}
+	if err.condition != frrk8sv1beta1.ConditionTranslated {
+		t.Fatalf("expected the error to refer to %s, got %s", frrk8sv1beta1.ConditionTranslated, err.condition)
+	}
+}
+
 var _ = BeforeSuite(func() {
 	logf.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
 
@@ -102,11 +168,48 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).ToNot(HaveOccurred())
 
+	err = k8sClient.Create(context.Background(), &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testNodeName,
+		},
+	})
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&FRRConfigurationReconciler{
-		Client:     k8sManager.GetClient(),
-		Scheme:     k8sManager.GetScheme(),
-		FRRHandler: &localFRR,
-		Logger:     log.NewNopLogger(),
+		Client:          k8sManager.GetClient(),
+		Scheme:          k8sManager.GetScheme(),
+		FRRHandler:      &localFRR,
+		Logger:          log.NewNopLogger(),
+		NodeName:        testNodeName,
+		DaemonNamespace: "default",
+		ReloadStatus:    make(chan event.GenericEvent),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	nodeStateEvents = make(chan event.GenericEvent)
+	err = (&FRRNodeStateReconciler{
+		Client:       k8sManager.GetClient(),
+		Scheme:       k8sManager.GetScheme(),
+		FRRStatus:    &localFRR,
+		Logger:       log.NewNopLogger(),
+		NodeName:     testNodeName,
+		ReloadStatus: nodeStateEvents,
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&BGPSessionStateReconciler{
+		Client:    k8sManager.GetClient(),
+		Scheme:    k8sManager.GetScheme(),
+		Logger:    log.NewNopLogger(),
+		NodeName:  testNodeName,
+		Namespace: "default",
+		BGPNeighbors: func() (map[string][]*frr.Neighbor, error) {
+			return bgpNeighbors, nil
+		},
+		BFDPeers: func() (map[string][]frr.BFDPeer, error) {
+			return map[string][]frr.BFDPeer{}, nil
+		},
+		ResyncPeriod: time.Second,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -130,10 +233,7 @@ var _ = AfterSuite(func() {
 var _ = Describe("Frrk8s controller", func() {
 	Context("when a FRRConfiguration is created", func() {
 		AfterEach(func() {
-			toDel := &frrk8sv1beta1.FRRConfiguration{}
-			toDel.Name = "test"
-			toDel.Namespace = "default"
-			err := k8sClient.Delete(context.Background(), toDel)
+			err := k8sClient.DeleteAllOf(context.Background(), &frrk8sv1beta1.FRRConfiguration{}, client.InNamespace("default"))
 			if apierrors.IsNotFound(err) {
 				return
 			}
@@ -266,5 +366,398 @@ var _ = Describe("Frrk8s controller", func() {
 				},
 			))
 		})
+
+		It("should merge multiple configurations", func() {
+			frrConfig := &frrk8sv1beta1.FRRConfiguration{
+				ObjectMeta: ctrl.ObjectMeta{
+					Name:      "test",
+					Namespace: "default",
+				},
+				Spec: frrk8sv1beta1.FRRConfigurationSpec{
+					BGP: frrk8sv1beta1.BGPConfig{
+						Routers: []frrk8sv1beta1.Router{
+							{
+								ASN:      uint32(42),
+								Prefixes: []string{"192.168.1.0/32"},
+							},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(context.Background(), frrConfig)
+			Expect(err).ToNot(HaveOccurred())
+
+			frrConfig1 := &frrk8sv1beta1.FRRConfiguration{
+				ObjectMeta: ctrl.ObjectMeta{
+					Name:      "test1",
+					Namespace: "default",
+				},
+				Spec: frrk8sv1beta1.FRRConfigurationSpec{
+					BGP: frrk8sv1beta1.BGPConfig{
+						Routers: []frrk8sv1beta1.Router{
+							{
+								ASN:      uint32(42),
+								Prefixes: []string{"192.168.2.0/32"},
+							},
+						},
+					},
+				},
+			}
+			err = k8sClient.Create(context.Background(), frrConfig1)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() *frr.Config {
+				return localFRR.lastConfig
+			}).Should(Equal(
+				&frr.Config{
+					Routers: []*frr.RouterConfig{{MyASN: uint32(42),
+						IPV4Prefixes: []string{"192.168.1.0/32", "192.168.2.0/32"},
+						IPV6Prefixes: []string{},
+						Neighbors:    []*frr.NeighborConfig{},
+					}},
+				},
+			))
+		})
+
+		It("should apply only the configurations matching the node selector", func() {
+			frrConfig := &frrk8sv1beta1.FRRConfiguration{
+				ObjectMeta: ctrl.ObjectMeta{
+					Name:      "test",
+					Namespace: "default",
+				},
+				Spec: frrk8sv1beta1.FRRConfigurationSpec{
+					BGP: frrk8sv1beta1.BGPConfig{
+						Routers: []frrk8sv1beta1.Router{
+							{
+								ASN:      uint32(42),
+								Prefixes: []string{"192.168.1.0/32"},
+							},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(context.Background(), frrConfig)
+			Expect(err).ToNot(HaveOccurred())
+
+			frrConfig1 := &frrk8sv1beta1.FRRConfiguration{
+				ObjectMeta: ctrl.ObjectMeta{
+					Name:      "test1",
+					Namespace: "default",
+				},
+				Spec: frrk8sv1beta1.FRRConfigurationSpec{
+					BGP: frrk8sv1beta1.BGPConfig{
+						Routers: []frrk8sv1beta1.Router{
+							{
+								ASN:      uint32(42),
+								Prefixes: []string{"192.168.2.0/32"},
+							},
+						},
+					},
+					NodeSelector: metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"rack": "rack1",
+						},
+					},
+				},
+			}
+			err = k8sClient.Create(context.Background(), frrConfig1)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() *frr.Config {
+				return localFRR.lastConfig
+			}).Should(Equal(
+				&frr.Config{
+					Routers: []*frr.RouterConfig{{MyASN: uint32(42),
+						IPV4Prefixes: []string{"192.168.1.0/32"},
+						IPV6Prefixes: []string{},
+						Neighbors:    []*frr.NeighborConfig{},
+					}},
+				},
+			))
+
+			By("labeling the node to match the selector")
+			node := &corev1.Node{}
+			err = k8sClient.Get(context.Background(), client.ObjectKey{Name: testNodeName}, node)
+			Expect(err).ToNot(HaveOccurred())
+			node.Labels = map[string]string{"rack": "rack1"}
+			err = k8sClient.Update(context.Background(), node)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() *frr.Config {
+				return localFRR.lastConfig
+			}).Should(Equal(
+				&frr.Config{
+					Routers: []*frr.RouterConfig{{MyASN: uint32(42),
+						IPV4Prefixes: []string{"192.168.1.0/32", "192.168.2.0/32"},
+						IPV6Prefixes: []string{},
+						Neighbors:    []*frr.NeighborConfig{},
+					}},
+				},
+			))
+
+			node.Labels = nil
+			err = k8sClient.Update(context.Background(), node)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should report the status of the configuration on the node", func() {
+			frrConfig := &frrk8sv1beta1.FRRConfiguration{
+				ObjectMeta: ctrl.ObjectMeta{
+					Name:      "test",
+					Namespace: "default",
+				},
+				Spec: frrk8sv1beta1.FRRConfigurationSpec{
+					BGP: frrk8sv1beta1.BGPConfig{
+						Routers: []frrk8sv1beta1.Router{
+							{
+								ASN: uint32(42),
+							},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(context.Background(), frrConfig)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() *frrk8sv1beta1.NodeStatus {
+				cfg := &frrk8sv1beta1.FRRConfiguration{}
+				err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(frrConfig), cfg)
+				Expect(err).ToNot(HaveOccurred())
+				if len(cfg.Status.Nodes) != 1 {
+					return nil
+				}
+				return &cfg.Status.Nodes[0]
+			}).Should(And(
+				HaveField("Node", testNodeName),
+				HaveField("LastAppliedGeneration", frrConfig.Generation),
+				HaveField("LastError", ""),
+				WithTransform(func(s *frrk8sv1beta1.NodeStatus) bool {
+					return meta.IsStatusConditionTrue(s.Conditions, frrk8sv1beta1.ConditionReloaded)
+				}, BeTrue()),
+			))
+
+			By("adding a conflicting configuration")
+			frrConfig1 := &frrk8sv1beta1.FRRConfiguration{
+				ObjectMeta: ctrl.ObjectMeta{
+					Name:      "test1",
+					Namespace: "default",
+				},
+				Spec: frrk8sv1beta1.FRRConfigurationSpec{
+					BGP: frrk8sv1beta1.BGPConfig{
+						Routers: []frrk8sv1beta1.Router{
+							{
+								ASN: uint32(43),
+							},
+						},
+					},
+				},
+			}
+			err = k8sClient.Create(context.Background(), frrConfig1)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() *frrk8sv1beta1.NodeStatus {
+				cfg := &frrk8sv1beta1.FRRConfiguration{}
+				err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(frrConfig1), cfg)
+				Expect(err).ToNot(HaveOccurred())
+				if len(cfg.Status.Nodes) != 1 {
+					return nil
+				}
+				return &cfg.Status.Nodes[0]
+			}).Should(And(
+				HaveField("Node", testNodeName),
+				HaveField("LastError", ContainSubstring("different asns")),
+				WithTransform(func(s *frrk8sv1beta1.NodeStatus) bool {
+					return meta.IsStatusConditionFalse(s.Conditions, frrk8sv1beta1.ConditionMerged)
+				}, BeTrue()),
+			))
+		})
+
+		It("should report the failure to apply the configuration", func() {
+			localFRR.mustError = true
+			defer func() {
+				localFRR.mustError = false
+			}()
+
+			frrConfig := &frrk8sv1beta1.FRRConfiguration{
+				ObjectMeta: ctrl.ObjectMeta{
+					Name:      "test",
+					Namespace: "default",
+				},
+				Spec: frrk8sv1beta1.FRRConfigurationSpec{
+					BGP: frrk8sv1beta1.BGPConfig{
+						Routers: []frrk8sv1beta1.Router{
+							{
+								ASN: uint32(42),
+							},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(context.Background(), frrConfig)
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(func() *frrk8sv1beta1.NodeStatus {
+				cfg := &frrk8sv1beta1.FRRConfiguration{}
+				err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(frrConfig), cfg)
+				Expect(err).ToNot(HaveOccurred())
+				if len(cfg.Status.Nodes) != 1 {
+					return nil
+				}
+				return &cfg.Status.Nodes[0]
+			}).Should(And(
+				HaveField("Node", testNodeName),
+				HaveField("LastAppliedGeneration", int64(0)),
+				HaveField("LastError", ContainSubstring("failed to apply the config")),
+				WithTransform(func(s *frrk8sv1beta1.NodeStatus) bool {
+					return meta.IsStatusConditionFalse(s.Conditions, frrk8sv1beta1.ConditionReloaded)
+				}, BeTrue()),
+			))
+		})
+	})
+
+	Context("when a neighbor references a password secret", func() {
+		AfterEach(func() {
+			err := k8sClient.DeleteAllOf(context.Background(), &frrk8sv1beta1.FRRConfiguration{}, client.InNamespace("default"))
+			Expect(err).ToNot(HaveOccurred())
+			err = k8sClient.DeleteAllOf(context.Background(), &corev1.Secret{}, client.InNamespace("default"))
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should apply the password and follow its rotation", func() {
+			frrConfig := &frrk8sv1beta1.FRRConfiguration{
+				ObjectMeta: ctrl.ObjectMeta{
+					Name:      "test",
+					Namespace: "default",
+				},
+				Spec: frrk8sv1beta1.FRRConfigurationSpec{
+					BGP: frrk8sv1beta1.BGPConfig{
+						Routers: []frrk8sv1beta1.Router{
+							{
+								ASN: uint32(42),
+								Neighbors: []frrk8sv1beta1.Neighbor{
+									{
+										ASN:            uint32(43),
+										Address:        "192.0.2.2",
+										PasswordSecret: corev1.SecretReference{Name: "bgp-password"},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(context.Background(), frrConfig)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("reporting the missing secret")
+			Eventually(func() string {
+				cfg := &frrk8sv1beta1.FRRConfiguration{}
+				err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(frrConfig), cfg)
+				Expect(err).ToNot(HaveOccurred())
+				if len(cfg.Status.Nodes) != 1 {
+					return ""
+				}
+				return cfg.Status.Nodes[0].LastError
+			}).Should(ContainSubstring("secret bgp-password not found"))
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "bgp-password",
+					Namespace: "default",
+				},
+				Type: corev1.SecretTypeBasicAuth,
+				Data: map[string][]byte{
+					corev1.BasicAuthPasswordKey: []byte("password1"),
+				},
+			}
+			err = k8sClient.Create(context.Background(), secret)
+			Expect(err).ToNot(HaveOccurred())
+
+			password := func() string {
+				if localFRR.lastConfig == nil || len(localFRR.lastConfig.Routers) != 1 ||
+					len(localFRR.lastConfig.Routers[0].Neighbors) != 1 {
+					return ""
+				}
+				return localFRR.lastConfig.Routers[0].Neighbors[0].Password
+			}
+			Eventually(password).Should(Equal("password1"))
+
+			By("rotating the password")
+			secret.Data[corev1.BasicAuthPasswordKey] = []byte("password2")
+			err = k8sClient.Update(context.Background(), secret)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(password).Should(Equal("password2"))
+		})
+	})
+
+	Context("when FRR is reloaded", func() {
+		It("should report the state of FRR on the node", func() {
+			localFRR.lastReloadResult = "success"
+			nodeStateEvents <- NewReloadStatusEvent()
+
+			Eventually(func() string {
+				state := &frrk8sv1beta1.FRRNodeState{}
+				err := k8sClient.Get(context.Background(), client.ObjectKey{Name: testNodeName}, state)
+				if apierrors.IsNotFound(err) {
+					return ""
+				}
+				Expect(err).ToNot(HaveOccurred())
+				return state.Status.LastReloadResult
+			}).Should(Equal("success"))
+
+			localFRR.lastReloadResult = "failure"
+			nodeStateEvents <- NewReloadStatusEvent()
+
+			Eventually(func() string {
+				state := &frrk8sv1beta1.FRRNodeState{}
+				err := k8sClient.Get(context.Background(), client.ObjectKey{Name: testNodeName}, state)
+				Expect(err).ToNot(HaveOccurred())
+				return state.Status.LastReloadResult
+			}).Should(Equal("failure"))
+		})
+	})
+
+	Context("when the BGP sessions change", func() {
+		It("should publish the state of the sessions of the node", func() {
+			bgpNeighbors = map[string][]*frr.Neighbor{
+				"default": {
+					{IP: net.ParseIP("192.168.1.2"), BGPState: "Established", PrefixSent: 1},
+					{IP: net.ParseIP("192.168.1.3"), BGPState: "Active"},
+				},
+			}
+
+			Eventually(func() map[string]string {
+				states := &frrk8sv1beta1.BGPSessionStateList{}
+				err := k8sClient.List(context.Background(), states, client.InNamespace("default"))
+				Expect(err).ToNot(HaveOccurred())
+				res := map[string]string{}
+				for _, s := range states.Items {
+					res[s.Status.Peer] = s.Status.BGPStatus
+				}
+				return res
+			}, 5*time.Second).Should(Equal(map[string]string{
+				"192.168.1.2": "Established",
+				"192.168.1.3": "Active",
+			}))
+
+			bgpNeighbors = map[string][]*frr.Neighbor{
+				"default": {
+					{IP: net.ParseIP("192.168.1.2"), BGPState: "Idle"},
+				},
+			}
+
+			Eventually(func() map[string]string {
+				states := &frrk8sv1beta1.BGPSessionStateList{}
+				err := k8sClient.List(context.Background(), states, client.InNamespace("default"))
+				Expect(err).ToNot(HaveOccurred())
+				res := map[string]string{}
+				for _, s := range states.Items {
+					res[s.Status.Peer] = s.Status.BGPStatus
+				}
+				return res
+			}, 5*time.Second).Should(Equal(map[string]string{
+				"192.168.1.2": "Idle",
+			}))
+		})
 	})
 })
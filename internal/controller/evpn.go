@@ -0,0 +1,143 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	v1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/internal/frr"
+)
+
+// maxVNI is the highest VXLAN network identifier, which is a 24 bits value.
+const maxVNI = 1<<24 - 1
+
+// evpnToFRR validates the l2vpn evpn settings of the given router. The
+// VNIs can be advertised only by the router of the default vrf.
+func evpnToFRR(r v1beta1.Router) (frr.EVPNConfig, error) {
+	e := r.EVPN
+	if r.VRF != "" && (e.AdvertiseAllVNI || len(e.VNIs) > 0) {
+		return frr.EVPNConfig{}, fmt.Errorf("the vnis can be advertised only by the router of the default vrf")
+	}
+
+	vnis := map[uint32]frr.VNIConfig{}
+	for _, v := range e.VNIs {
+		if v.VNI == 0 || v.VNI > maxVNI {
+			return frr.EVPNConfig{}, fmt.Errorf("invalid vni %d, must be between 1 and %d", v.VNI, maxVNI)
+		}
+		if _, ok := vnis[v.VNI]; ok {
+			return frr.EVPNConfig{}, fmt.Errorf("duplicate vni %d", v.VNI)
+		}
+		if err := validateRouteTargets(v.RD, v.ImportRTs, v.ExportRTs); err != nil {
+			return frr.EVPNConfig{}, fmt.Errorf("invalid vni %d: %w", v.VNI, err)
+		}
+		vnis[v.VNI] = frr.VNIConfig{
+			VNI:       v.VNI,
+			RD:        v.RD,
+			ImportRTs: sortedCommunities(v.ImportRTs),
+			ExportRTs: sortedCommunities(v.ExportRTs),
+		}
+	}
+
+	if err := validateRouteTargets(e.RD, e.ImportRTs, e.ExportRTs); err != nil {
+		return frr.EVPNConfig{}, err
+	}
+	return frr.EVPNConfig{
+		AdvertiseAllVNI:      e.AdvertiseAllVNI,
+		VNIs:                 sortedVNIs(vnis),
+		AdvertiseIPv4Unicast: e.AdvertiseIPv4Unicast,
+		AdvertiseIPv6Unicast: e.AdvertiseIPv6Unicast,
+		RD:                   e.RD,
+		ImportRTs:            sortedCommunities(e.ImportRTs),
+		ExportRTs:            sortedCommunities(e.ExportRTs),
+	}, nil
+}
+
+// validateRouteTargets checks that the given route distinguisher, if any,
+// and route targets are in the AS:value or IP:value forms.
+func validateRouteTargets(rd string, importRTs, exportRTs []string) error {
+	if rd != "" {
+		if err := validateExtendedCommunity(rd); err != nil {
+			return fmt.Errorf("invalid route distinguisher %s, %w", rd, err)
+		}
+	}
+	for _, targets := range [][]string{importRTs, exportRTs} {
+		for _, t := range targets {
+			if err := validateExtendedCommunity(t); err != nil {
+				return fmt.Errorf("invalid route target %s, %w", t, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sortedVNIs returns the given VNIs sorted by identifier, or nil if
+// there are none.
+func sortedVNIs(vnis map[uint32]frr.VNIConfig) []frr.VNIConfig {
+	if len(vnis) == 0 {
+		return nil
+	}
+	res := make([]frr.VNIConfig, 0, len(vnis))
+	for _, v := range vnis {
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].VNI < res[j].VNI
+	})
+	return res
+}
+
+// mergeEVPN merges the l2vpn evpn settings of two routers. The route
+// distinguishers of the vrf and of the same VNI are allowed to be different
+// only if one of the two is not set, while the route targets are unioned.
+func mergeEVPN(curr, toMerge frr.EVPNConfig) (frr.EVPNConfig, error) {
+	rd, err := mergeRD(curr.RD, toMerge.RD)
+	if err != nil {
+		return frr.EVPNConfig{}, err
+	}
+
+	vnis := map[uint32]frr.VNIConfig{}
+	for _, v := range curr.VNIs {
+		vnis[v.VNI] = v
+	}
+	for _, v := range toMerge.VNIs {
+		existing, ok := vnis[v.VNI]
+		if !ok {
+			vnis[v.VNI] = v
+			continue
+		}
+		vniRD, err := mergeRD(existing.RD, v.RD)
+		if err != nil {
+			return frr.EVPNConfig{}, fmt.Errorf("%w for vni %d", err, v.VNI)
+		}
+		vnis[v.VNI] = frr.VNIConfig{
+			VNI:       v.VNI,
+			RD:        vniRD,
+			ImportRTs: sortedCommunities(append(existing.ImportRTs, v.ImportRTs...)),
+			ExportRTs: sortedCommunities(append(existing.ExportRTs, v.ExportRTs...)),
+		}
+	}
+
+	return frr.EVPNConfig{
+		AdvertiseAllVNI:      curr.AdvertiseAllVNI || toMerge.AdvertiseAllVNI,
+		VNIs:                 sortedVNIs(vnis),
+		AdvertiseIPv4Unicast: curr.AdvertiseIPv4Unicast || toMerge.AdvertiseIPv4Unicast,
+		AdvertiseIPv6Unicast: curr.AdvertiseIPv6Unicast || toMerge.AdvertiseIPv6Unicast,
+		RD:                   rd,
+		ImportRTs:            sortedCommunities(append(curr.ImportRTs, toMerge.ImportRTs...)),
+		ExportRTs:            sortedCommunities(append(curr.ExportRTs, toMerge.ExportRTs...)),
+	}, nil
+}
+
+// mergeRD returns the route distinguisher set in any of the two, failing
+// if they are both set to different values.
+func mergeRD(curr, toMerge string) (string, error) {
+	if curr != "" && toMerge != "" && curr != toMerge {
+		return "", fmt.Errorf("different route distinguishers (%s != %s)", curr, toMerge)
+	}
+	if curr == "" {
+		return toMerge, nil
+	}
+	return curr, nil
+}
@@ -18,10 +18,24 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -35,11 +49,32 @@ type FRRConfigurationReconciler struct {
 	Scheme     *runtime.Scheme
 	FRRHandler frr.ConfigHandler
 	Logger     log.Logger
+	NodeName   string
+	// DaemonNamespace is the namespace the daemon runs in, where the
+	// secrets containing the BGP passwords are read from.
+	DaemonNamespace string
+	// ReloadStatus receives an event every time FRR is reloaded, so
+	// that the status of the configurations can be updated.
+	ReloadStatus chan event.GenericEvent
+}
+
+// NewReloadStatusEvent returns the event to be sent to the reconciler
+// when the reload status of FRR changes.
+func NewReloadStatusEvent() event.GenericEvent {
+	return event.GenericEvent{
+		Object: &frrk8sv1beta1.FRRConfiguration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "reloadstatus",
+			},
+		},
+	}
 }
 
 // +kubebuilder:rbac:groups=frrk8s.metallb.io,resources=frrconfigurations,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=frrk8s.metallb.io,resources=frrconfigurations/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=frrk8s.metallb.io,resources=frrconfigurations/finalizers,verbs=update
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch,namespace=system
 
 func (r *FRRConfigurationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	level.Info(r.Logger).Log("controller", "FRRConfigurationReconciler", "start reconcile", req.NamespacedName.String())
@@ -50,40 +85,181 @@ func (r *FRRConfigurationReconciler) Reconcile(ctx context.Context, req ctrl.Req
 	if err != nil {
 		return ctrl.Result{}, err
 	}
-	if len(configs.Items) > 1 { // TODO implement merging
-		return ctrl.Result{}, nil
+
+	thisNode := &corev1.Node{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: r.NodeName}, thisNode)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
-	if len(configs.Items) == 0 {
-		empty := frrk8sv1beta1.FRRConfiguration{}
-		config, err := apiToFRR(empty)
-		if err != nil {
-			level.Error(r.Logger).Log("controller", "FRRConfigurationReconciler", "failed to translate the empty config", req.NamespacedName.String(), "error", err)
-			return ctrl.Result{}, nil
-		}
+	secrets := corev1.SecretList{}
+	err = r.Client.List(ctx, &secrets, client.InNamespace(r.DaemonNamespace))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 
-		if err := r.FRRHandler.ApplyConfig(config); err != nil {
-			level.Error(r.Logger).Log("controller", "FRRConfigurationReconciler", "failed to apply the empty config", req.NamespacedName.String(), "error", err)
-		}
-		return ctrl.Result{}, nil
+	toApply, invalid := configsForNode(configs.Items, thisNode.Labels)
+	for _, err := range invalid {
+		level.Error(r.Logger).Log("controller", "FRRConfigurationReconciler", "failed to select the configs for the node", req.NamespacedName.String(), "error", err)
 	}
-	config, err := apiToFRR(configs.Items[0])
+
+	config, err := apiToFRR(clusterResources{
+		FRRConfigs:      toApply,
+		PasswordSecrets: secretsByName(secrets.Items),
+		NodeAnnotations: thisNode.Annotations,
+	})
 	if err != nil {
-		level.Error(r.Logger).Log("controller", "FRRConfigurationReconciler", "failed to apply the config", req.NamespacedName.String(), "error", err)
-		return ctrl.Result{}, nil
+		level.Error(r.Logger).Log("controller", "FRRConfigurationReconciler", "failed to translate the config", req.NamespacedName.String(), "error", err)
+		return ctrl.Result{}, r.updateStatus(ctx, configs.Items, toApply, invalid, err, nil, r.FRRHandler.ReloadStatus())
 	}
 
-	if err := r.FRRHandler.ApplyConfig(config); err != nil {
+	err = r.FRRHandler.ApplyConfig(config)
+	if err != nil {
 		level.Error(r.Logger).Log("controller", "FRRConfigurationReconciler", "failed to apply the config", req.NamespacedName.String(), "error", err)
-		return ctrl.Result{}, nil
+		// The configuration never reached FRR, so the failure is reported as the
+		// outcome of its reload and the request is retried.
+		failed := frr.ReloadStatus{Config: config, Error: fmt.Errorf("failed to apply the config: %w", err)}
+		statusErr := r.updateStatus(ctx, configs.Items, toApply, invalid, nil, config, failed)
+		return ctrl.Result{}, utilerrors.NewAggregate([]error{err, statusErr})
+	}
+
+	return ctrl.Result{}, r.updateStatus(ctx, configs.Items, toApply, invalid, nil, config, r.FRRHandler.ReloadStatus())
+}
+
+// updateStatus updates the status related to this node of all the configurations,
+// removing it from the ones not selecting the node. invalid are the errors of
+// the configurations whose node selector can't be parsed, err is the error
+// returned while translating and merging the selected configurations, and
+// reload is the outcome of the reload of FRR with the applied configuration.
+func (r *FRRConfigurationReconciler) updateStatus(ctx context.Context, configs, selected []frrk8sv1beta1.FRRConfiguration,
+	invalid map[types.NamespacedName]*configError, err error, applied *frr.Config, reload frr.ReloadStatus) error {
+	var applyErr *configError
+	if err != nil && !errors.As(err, &applyErr) {
+		return nil
+	}
+
+	positions := map[types.NamespacedName]int{}
+	for i, cfg := range selected {
+		positions[types.NamespacedName{Namespace: cfg.Namespace, Name: cfg.Name}] = i
+	}
+
+	errs := []error{}
+	for i := range configs {
+		cfg := &configs[i]
+		key := types.NamespacedName{Namespace: cfg.Namespace, Name: cfg.Name}
+		index := nodeStatusIndex(cfg.Status.Nodes, r.NodeName)
+		pos, ok := positions[key]
+		cfgErr := applyErr
+		if selectorErr, found := invalid[key]; found {
+			pos, ok, cfgErr = 0, true, selectorErr
+		}
+
+		var current, status *frrk8sv1beta1.NodeStatus
+		if index != -1 {
+			current = &cfg.Status.Nodes[index]
+		}
+		switch {
+		case !ok && current == nil:
+			continue
+		case ok:
+			status = nodeStatusFor(current, r.NodeName, *cfg, pos, cfgErr, applied, reload)
+			if current != nil && equality.Semantic.DeepEqual(status, current) {
+				continue
+			}
+		}
+
+		if err := r.applyNodeStatus(ctx, cfg, status); err != nil {
+			errs = append(errs, fmt.Errorf("failed to update the status of %s: %w", key, err))
+		}
+	}
+	if len(errs) > 0 {
+		level.Error(r.Logger).Log("controller", "FRRConfigurationReconciler", "failed to update the status", "error", utilerrors.NewAggregate(errs))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// applyNodeStatus sets the status of this node in the given configuration,
+// removing it if status is nil. The status is applied server side with a field
+// manager per node, so that each node owns only its own entry and the nodes
+// don't conflict with each other.
+func (r *FRRConfigurationReconciler) applyNodeStatus(ctx context.Context, cfg *frrk8sv1beta1.FRRConfiguration, status *frrk8sv1beta1.NodeStatus) error {
+	nodes := []interface{}{}
+	if status != nil {
+		s, err := runtime.DefaultUnstructuredConverter.ToUnstructured(status)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, s)
 	}
+	patch := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": frrk8sv1beta1.GroupVersion.String(),
+		"kind":       "FRRConfiguration",
+		"metadata": map[string]interface{}{
+			"name":      cfg.Name,
+			"namespace": cfg.Namespace,
+		},
+		"status": map[string]interface{}{
+			"nodes": nodes,
+		},
+	}}
 
-	return ctrl.Result{}, nil
+	force := true
+	err := r.Client.Status().Patch(ctx, patch, client.Apply, &client.SubResourcePatchOptions{
+		PatchOptions: client.PatchOptions{FieldManager: statusFieldManager(r.NodeName), Force: &force},
+	})
+	return client.IgnoreNotFound(err)
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *FRRConfigurationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	thisNode := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetName() == r.NodeName
+	})
+
+	daemonNamespace := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetNamespace() == r.DaemonNamespace
+	})
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&frrk8sv1beta1.FRRConfiguration{}).
+		For(&frrk8sv1beta1.FRRConfiguration{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &corev1.Node{}}, &handler.EnqueueRequestForObject{},
+			builder.WithPredicates(thisNode, predicate.Or(predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForObject{},
+			builder.WithPredicates(daemonNamespace)).
+		Watches(&source.Channel{Source: r.ReloadStatus}, &handler.EnqueueRequestForObject{}).
 		Complete(r)
 }
+
+// configsForNode returns the configurations whose node selector matches
+// the given node labels. The configurations whose selector can't be parsed
+// are skipped, and returned indexed by name along with the related error.
+func configsForNode(configs []frrk8sv1beta1.FRRConfiguration, nodeLabels map[string]string) ([]frrk8sv1beta1.FRRConfiguration,
+	map[types.NamespacedName]*configError) {
+	res := make([]frrk8sv1beta1.FRRConfiguration, 0)
+	invalid := map[types.NamespacedName]*configError{}
+	for _, c := range configs {
+		selector, err := metav1.LabelSelectorAsSelector(&c.Spec.NodeSelector)
+		if err != nil {
+			invalid[types.NamespacedName{Namespace: c.Namespace, Name: c.Name}] = &configError{
+				index:     0,
+				condition: frrk8sv1beta1.ConditionTranslated,
+				err:       fmt.Errorf("failed to parse the node selector of %s/%s: %w", c.Namespace, c.Name, err),
+			}
+			continue
+		}
+		if !selector.Matches(labels.Set(nodeLabels)) {
+			continue
+		}
+		res = append(res, c)
+	}
+	return res, invalid
+}
+
+// secretsByName indexes the given secrets by their name.
+func secretsByName(secrets []corev1.Secret) map[string]corev1.Secret {
+	res := make(map[string]corev1.Secret, len(secrets))
+	for _, s := range secrets {
+		res[s.Name] = s
+	}
+	return res
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	frrk8sv1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/internal/frr"
+)
+
+// FRRNodeStateReconciler reconciles the FRRNodeState object of the node.
+type FRRNodeStateReconciler struct {
+	client.Client
+	Scheme    *runtime.Scheme
+	FRRStatus frr.StatusFetcher
+	Logger    log.Logger
+	NodeName  string
+	// ReloadStatus receives an event every time the reload status
+	// of FRR changes, so that the node state can be updated.
+	ReloadStatus chan event.GenericEvent
+}
+
+// +kubebuilder:rbac:groups=frrk8s.metallb.io,resources=frrnodestates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=frrk8s.metallb.io,resources=frrnodestates/status,verbs=get;update;patch
+
+func (r *FRRNodeStateReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	level.Info(r.Logger).Log("controller", "FRRNodeStateReconciler", "start reconcile", req.NamespacedName.String())
+	defer level.Info(r.Logger).Log("controller", "FRRNodeStateReconciler", "end reconcile", req.NamespacedName.String())
+
+	thisNode := &corev1.Node{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: r.NodeName}, thisNode)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The state is owned by the node, so that it gets deleted together with it.
+	state := &frrk8sv1beta1.FRRNodeState{ObjectMeta: metav1.ObjectMeta{Name: r.NodeName}}
+	_, err = controllerutil.CreateOrUpdate(ctx, r.Client, state, func() error {
+		return controllerutil.SetOwnerReference(thisNode, state, r.Scheme)
+	})
+	if err != nil {
+		level.Error(r.Logger).Log("controller", "FRRNodeStateReconciler", "failed to create the node state", req.NamespacedName.String(), "error", err)
+		return ctrl.Result{}, err
+	}
+
+	status := nodeStateStatusFor(r.FRRStatus.ReloadStatus())
+	if equality.Semantic.DeepEqual(status, state.Status) {
+		return ctrl.Result{}, nil
+	}
+	state.Status = status
+	err = r.Client.Status().Update(ctx, state)
+	if err != nil {
+		level.Error(r.Logger).Log("controller", "FRRNodeStateReconciler", "failed to update the node state", req.NamespacedName.String(), "error", err)
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *FRRNodeStateReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	thisNode := predicate.NewPredicateFuncs(func(o client.Object) bool {
+		return o.GetName() == r.NodeName
+	})
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&frrk8sv1beta1.FRRNodeState{}, builder.WithPredicates(thisNode)).
+		Watches(&source.Channel{Source: r.ReloadStatus}, &handler.EnqueueRequestForObject{}).
+		Complete(r)
+}
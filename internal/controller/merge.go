@@ -0,0 +1,429 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/metallb/frrk8s/internal/frr"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// mergeConfigs merges toMerge into curr, returning a new config. Routers
+// are merged by VRF, neighbors by their ID, and an error is returned if the
+// two configurations contain settings that can't be reconciled.
+func mergeConfigs(curr, toMerge *frr.Config) (*frr.Config, error) {
+	bfdProfiles, err := mergeBFDProfiles(curr.BFDProfiles, toMerge.BFDProfiles)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &frr.Config{
+		Routers:     make([]*frr.RouterConfig, 0),
+		BFDProfiles: bfdProfiles,
+	}
+
+	routers := map[string]*frr.RouterConfig{}
+	for _, r := range curr.Routers {
+		routers[r.VRF] = r
+	}
+
+	for _, r := range toMerge.Routers {
+		existing, ok := routers[r.VRF]
+		if !ok {
+			existing = &frr.RouterConfig{
+				MyASN:    r.MyASN,
+				RouterID: r.RouterID,
+				VRF:      r.VRF,
+			}
+		}
+		merged, err := mergeRouterConfigs(existing, r)
+		if err != nil {
+			return nil, err
+		}
+		routers[r.VRF] = merged
+	}
+
+	for _, r := range routers {
+		res.Routers = append(res.Routers, r)
+	}
+	sort.Slice(res.Routers, func(i, j int) bool {
+		return res.Routers[i].VRF < res.Routers[j].VRF
+	})
+
+	return res, nil
+}
+
+// mergeRouterConfigs merges two routers belonging to the same VRF.
+func mergeRouterConfigs(r, toMerge *frr.RouterConfig) (*frr.RouterConfig, error) {
+	if r.MyASN != toMerge.MyASN {
+		return nil, fmt.Errorf("different asns (%d != %d) specified for same vrf: %s", r.MyASN, toMerge.MyASN, r.VRF)
+	}
+
+	routerID := r.RouterID
+	if routerID == "" {
+		routerID = toMerge.RouterID
+	}
+	if toMerge.RouterID != "" && routerID != toMerge.RouterID {
+		return nil, fmt.Errorf("different router ids (%s != %s) specified for same vrf: %s", r.RouterID, toMerge.RouterID, r.VRF)
+	}
+
+	gracefulRestart, err := mergeGracefulRestart(r.GracefulRestart, toMerge.GracefulRestart)
+	if err != nil {
+		return nil, fmt.Errorf("%w specified for same vrf: %s", err, r.VRF)
+	}
+	routeTargets, err := mergeRouteTargets(r.RouteTargets, toMerge.RouteTargets)
+	if err != nil {
+		return nil, fmt.Errorf("%w specified for same vrf: %s", err, r.VRF)
+	}
+	evpn, err := mergeEVPN(r.EVPN, toMerge.EVPN)
+	if err != nil {
+		return nil, fmt.Errorf("%w specified in evpn for same vrf: %s", err, r.VRF)
+	}
+	multipath, err := mergeMultipath(r.Multipath, toMerge.Multipath)
+	if err != nil {
+		return nil, fmt.Errorf("%w specified for same vrf: %s", err, r.VRF)
+	}
+	aggregatesV4, err := mergeAggregates(r.AggregatesV4, toMerge.AggregatesV4)
+	if err != nil {
+		return nil, fmt.Errorf("%w specified for same vrf: %s", err, r.VRF)
+	}
+	aggregatesV6, err := mergeAggregates(r.AggregatesV6, toMerge.AggregatesV6)
+	if err != nil {
+		return nil, fmt.Errorf("%w specified for same vrf: %s", err, r.VRF)
+	}
+	redistribute, err := mergeRedistribute(r.Redistribute, toMerge.Redistribute)
+	if err != nil {
+		return nil, fmt.Errorf("%w specified for same vrf: %s", err, r.VRF)
+	}
+
+	neighbors, err := mergeNeighbors(r.Neighbors, toMerge.Neighbors)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateListenRanges(neighbors); err != nil {
+		return nil, err
+	}
+	peerGroups, err := mergePeerGroups(r.PeerGroups, toMerge.PeerGroups)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePeerGroups(peerGroups, neighbors); err != nil {
+		return nil, err
+	}
+
+	return &frr.RouterConfig{
+		MyASN:           r.MyASN,
+		RouterID:        routerID,
+		VRF:             r.VRF,
+		Neighbors:       neighbors,
+		IPV4Prefixes:    mergePrefixes(r.IPV4Prefixes, toMerge.IPV4Prefixes),
+		IPV6Prefixes:    mergePrefixes(r.IPV6Prefixes, toMerge.IPV6Prefixes),
+		PeerGroups:      peerGroups,
+		GracefulRestart: gracefulRestart,
+		Imports:         mergeImports(r.Imports, toMerge.Imports),
+		RouteTargets:    routeTargets,
+		EVPN:            evpn,
+		Multipath:       multipath,
+		AggregatesV4:    aggregatesV4,
+		AggregatesV6:    aggregatesV6,
+		Redistribute:    redistribute,
+	}, nil
+}
+
+// mergeMultipath merges the multipath settings of two routers, each maximum
+// being allowed to be specified by one of the two only or to have the same
+// value in both.
+func mergeMultipath(curr, toMerge frr.MultipathConfig) (frr.MultipathConfig, error) {
+	res := curr
+	if res.MaximumPathsEBGP == 0 {
+		res.MaximumPathsEBGP = toMerge.MaximumPathsEBGP
+	}
+	if toMerge.MaximumPathsEBGP != 0 && res.MaximumPathsEBGP != toMerge.MaximumPathsEBGP {
+		return frr.MultipathConfig{}, fmt.Errorf("different maximum ebgp paths (%d != %d)", res.MaximumPathsEBGP, toMerge.MaximumPathsEBGP)
+	}
+	if res.MaximumPathsIBGP == 0 {
+		res.MaximumPathsIBGP = toMerge.MaximumPathsIBGP
+	}
+	if toMerge.MaximumPathsIBGP != 0 && res.MaximumPathsIBGP != toMerge.MaximumPathsIBGP {
+		return frr.MultipathConfig{}, fmt.Errorf("different maximum ibgp paths (%d != %d)", res.MaximumPathsIBGP, toMerge.MaximumPathsIBGP)
+	}
+	res.ASPathMultipathRelax = curr.ASPathMultipathRelax || toMerge.ASPathMultipathRelax
+	return res, nil
+}
+
+// mergeGracefulRestart merges the graceful restart settings of two routers,
+// each setting being allowed to be specified by one of the two only or to
+// have the same value in both.
+func mergeGracefulRestart(curr, toMerge frr.GracefulRestartConfig) (frr.GracefulRestartConfig, error) {
+	res := curr
+	if res.Mode == "" {
+		res.Mode = toMerge.Mode
+	}
+	if toMerge.Mode != "" && res.Mode != toMerge.Mode {
+		return frr.GracefulRestartConfig{}, fmt.Errorf("different graceful restart modes (%s != %s)", res.Mode, toMerge.Mode)
+	}
+	if res.RestartTime == 0 {
+		res.RestartTime = toMerge.RestartTime
+	}
+	if toMerge.RestartTime != 0 && res.RestartTime != toMerge.RestartTime {
+		return frr.GracefulRestartConfig{}, fmt.Errorf("different graceful restart times (%d != %d)", res.RestartTime, toMerge.RestartTime)
+	}
+	if res.StalePathTime == 0 {
+		res.StalePathTime = toMerge.StalePathTime
+	}
+	if toMerge.StalePathTime != 0 && res.StalePathTime != toMerge.StalePathTime {
+		return frr.GracefulRestartConfig{}, fmt.Errorf("different graceful restart stale path times (%d != %d)", res.StalePathTime, toMerge.StalePathTime)
+	}
+	return res, nil
+}
+
+// mergeNeighbors merges two lists of neighbors belonging to the same router,
+// deduplicating them by their ID (address and vrf).
+func mergeNeighbors(curr, toMerge []*frr.NeighborConfig) ([]*frr.NeighborConfig, error) {
+	neighbors := map[string]*frr.NeighborConfig{}
+	for _, n := range curr {
+		neighbors[n.ID()] = n
+	}
+
+	for _, n := range toMerge {
+		existing, ok := neighbors[n.ID()]
+		if !ok {
+			empty := *n
+			empty.Advertisements = nil
+			empty.HasV4Advertisements = false
+			empty.HasV6Advertisements = false
+			empty.Incoming = frr.AllowedIn{}
+			empty.AdvertiseRedistributed = false
+			empty.ListenRanges = nil
+			existing = &empty
+		}
+		if err := neighborsAreCompatible(existing, n); err != nil {
+			return nil, err
+		}
+
+		advertisements, err := mergeAdvertisements(existing.Advertisements, n.Advertisements)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge advertisements for neighbor %s: %w", n.ID(), err)
+		}
+		merged := *existing
+		merged.Advertisements = advertisements
+		merged.HasV4Advertisements = existing.HasV4Advertisements || n.HasV4Advertisements
+		merged.HasV6Advertisements = existing.HasV6Advertisements || n.HasV6Advertisements
+		merged.Incoming = mergeAllowedIncoming(existing.Incoming, n.Incoming)
+		merged.AdvertiseRedistributed = existing.AdvertiseRedistributed || n.AdvertiseRedistributed
+		if len(existing.ListenRanges) > 0 || len(n.ListenRanges) > 0 {
+			merged.ListenRanges = mergePrefixes(existing.ListenRanges, n.ListenRanges)
+			merged.IPFamily = listenRangesFamily(merged.ListenRanges)
+		}
+		neighbors[n.ID()] = &merged
+	}
+
+	res := make([]*frr.NeighborConfig, 0, len(neighbors))
+	for _, n := range neighbors {
+		res = append(res, n)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID() < res[j].ID()
+	})
+	return res, nil
+}
+
+// neighborsAreCompatible returns an error if the two neighbors, which are
+// expected to share the same ID, have conflicting session parameters.
+func neighborsAreCompatible(n1, n2 *frr.NeighborConfig) error {
+	neighborID := n1.ID()
+	if n1.PeerGroup != n2.PeerGroup || n1.Iface != n2.Iface || n1.Addr != n2.Addr {
+		return fmt.Errorf("multiple kinds of neighbors specified for %s", neighborID)
+	}
+	if n1.ASN != n2.ASN || n1.DynamicASN != n2.DynamicASN {
+		return fmt.Errorf("multiple asns specified for %s", neighborID)
+	}
+	if templateName(n1) != templateName(n2) {
+		return fmt.Errorf("multiple templates specified for %s", neighborID)
+	}
+	if n1.Port != n2.Port {
+		return fmt.Errorf("multiple ports specified for %s", neighborID)
+	}
+	if n1.SrcAddr != n2.SrcAddr {
+		return fmt.Errorf("multiple source addresses specified for %s", neighborID)
+	}
+	if n1.Password != n2.Password {
+		return fmt.Errorf("multiple passwords specified for %s", neighborID)
+	}
+	if n1.HoldTime != n2.HoldTime {
+		return fmt.Errorf("multiple hold times specified for %s", neighborID)
+	}
+	if n1.KeepaliveTime != n2.KeepaliveTime {
+		return fmt.Errorf("multiple keepalive times specified for %s", neighborID)
+	}
+	if n1.BFDProfile != n2.BFDProfile {
+		return fmt.Errorf("multiple bfd profiles specified for %s", neighborID)
+	}
+	if n1.EBGPMultiHop != n2.EBGPMultiHop {
+		return fmt.Errorf("conflicting ebgp-multihop specified for %s", neighborID)
+	}
+	if n1.GracefulRestart != n2.GracefulRestart {
+		return fmt.Errorf("multiple graceful restart modes specified for %s", neighborID)
+	}
+	if !reflect.DeepEqual(n1.MaximumPrefixV4, n2.MaximumPrefixV4) || !reflect.DeepEqual(n1.MaximumPrefixV6, n2.MaximumPrefixV6) {
+		return fmt.Errorf("multiple maximum prefixes specified for %s", neighborID)
+	}
+	return nil
+}
+
+// templateName returns the name of the peer group the neighbor is a
+// member of, if any.
+func templateName(n *frr.NeighborConfig) string {
+	if n.Template == nil {
+		return ""
+	}
+	return n.Template.Name
+}
+
+// mergeAdvertisements unions two lists of advertisements, merging the properties
+// of the ones related to the same prefix.
+func mergeAdvertisements(curr, toMerge []*frr.AdvertisementConfig) ([]*frr.AdvertisementConfig, error) {
+	advertisements := map[string]*frr.AdvertisementConfig{}
+	for _, a := range curr {
+		advertisements[a.Prefix] = a
+	}
+
+	for _, a := range toMerge {
+		existing, ok := advertisements[a.Prefix]
+		if !ok {
+			advertisements[a.Prefix] = a
+			continue
+		}
+		if existing.LocalPref != 0 && a.LocalPref != 0 && existing.LocalPref != a.LocalPref {
+			return nil, fmt.Errorf("multiple local prefs (%d != %d) specified for prefix %s", existing.LocalPref, a.LocalPref, a.Prefix)
+		}
+		if existing.ASPathPrepend.Times != 0 && a.ASPathPrepend.Times != 0 && existing.ASPathPrepend != a.ASPathPrepend {
+			return nil, fmt.Errorf("multiple as path prepends (%s != %s) specified for prefix %s", existing.ASPathPrepend, a.ASPathPrepend, a.Prefix)
+		}
+		if existing.MED != nil && a.MED != nil && *existing.MED != *a.MED {
+			return nil, fmt.Errorf("multiple meds (%d != %d) specified for prefix %s", *existing.MED, *a.MED, a.Prefix)
+		}
+		if existing.Origin != "" && a.Origin != "" && existing.Origin != a.Origin {
+			return nil, fmt.Errorf("multiple origins (%s != %s) specified for prefix %s", existing.Origin, a.Origin, a.Prefix)
+		}
+		merged := *existing
+		if merged.MED == nil {
+			merged.MED = a.MED
+		}
+		if merged.Origin == "" {
+			merged.Origin = a.Origin
+		}
+		if merged.LocalPref == 0 {
+			merged.LocalPref = a.LocalPref
+		}
+		if merged.ASPathPrepend.Times == 0 {
+			merged.ASPathPrepend = a.ASPathPrepend
+		}
+		merged.Communities = sortedCommunities(append(existing.Communities, a.Communities...))
+		merged.LargeCommunities = sortedCommunities(append(existing.LargeCommunities, a.LargeCommunities...))
+		merged.ExtendedCommunities = sortedCommunities(append(existing.ExtendedCommunities, a.ExtendedCommunities...))
+		advertisements[a.Prefix] = &merged
+	}
+
+	res := make([]*frr.AdvertisementConfig, 0, len(advertisements))
+	for _, a := range advertisements {
+		res = append(res, a)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Prefix < res[j].Prefix
+	})
+	return res, nil
+}
+
+// mergeAllowedIncoming unions the prefixes allowed to be received from a
+// neighbor. If any of the two accepts all the prefixes, the result does too.
+func mergeAllowedIncoming(curr, toMerge frr.AllowedIn) frr.AllowedIn {
+	if curr.All || toMerge.All {
+		return frr.AllowedIn{All: true}
+	}
+	return frr.AllowedIn{
+		PrefixesV4: mergeIncomingFilters(curr.PrefixesV4, toMerge.PrefixesV4),
+		PrefixesV6: mergeIncomingFilters(curr.PrefixesV6, toMerge.PrefixesV6),
+	}
+}
+
+// mergeIncomingFilters returns the sorted union of the given filters.
+func mergeIncomingFilters(curr, toMerge []frr.IncomingFilter) []frr.IncomingFilter {
+	if len(curr) == 0 && len(toMerge) == 0 {
+		return nil
+	}
+	filters := map[frr.IncomingFilter]struct{}{}
+	for _, f := range curr {
+		filters[f] = struct{}{}
+	}
+	for _, f := range toMerge {
+		filters[f] = struct{}{}
+	}
+
+	res := make([]frr.IncomingFilter, 0, len(filters))
+	for f := range filters {
+		res = append(res, f)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Prefix != res[j].Prefix {
+			return res[i].Prefix < res[j].Prefix
+		}
+		if res[i].GE != res[j].GE {
+			return res[i].GE < res[j].GE
+		}
+		return res[i].LE < res[j].LE
+	})
+	return res
+}
+
+// mergeBFDProfiles unions two lists of bfd profiles. Profiles with the
+// same name are allowed only if they are identical.
+func mergeBFDProfiles(curr, toMerge []frr.BFDProfile) ([]frr.BFDProfile, error) {
+	profiles := map[string]frr.BFDProfile{}
+	for _, p := range curr {
+		profiles[p.Name] = p
+	}
+
+	for _, p := range toMerge {
+		existing, ok := profiles[p.Name]
+		if ok && !reflect.DeepEqual(existing, p) {
+			return nil, fmt.Errorf("multiple bfd profiles named %s with different settings", p.Name)
+		}
+		profiles[p.Name] = p
+	}
+
+	res := make([]frr.BFDProfile, 0, len(profiles))
+	for _, p := range profiles {
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res, nil
+}
+
+// mergePeerGroups unions two lists of peer groups. Groups with the same
+// name are allowed only if they are identical.
+func mergePeerGroups(curr, toMerge []*frr.PeerGroupConfig) ([]*frr.PeerGroupConfig, error) {
+	groups := map[string]*frr.PeerGroupConfig{}
+	for _, g := range curr {
+		groups[g.Name] = g
+	}
+
+	for _, g := range toMerge {
+		existing, ok := groups[g.Name]
+		if ok && !reflect.DeepEqual(existing, g) {
+			return nil, fmt.Errorf("multiple neighbor templates named %s with different settings", g.Name)
+		}
+		groups[g.Name] = g
+	}
+	return sortedPeerGroups(groups), nil
+}
+
+// mergePrefixes returns the sorted union of the given lists of prefixes.
+func mergePrefixes(curr, toMerge []string) []string {
+	return sets.List(sets.New(curr...).Insert(toMerge...))
+}
@@ -0,0 +1,151 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	v1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/internal/frr"
+	v1 "k8s.io/api/core/v1"
+)
+
+// neighborTemplatesByName validates the given templates and indexes them
+// by name.
+func neighborTemplatesByName(templates []v1beta1.NeighborTemplate) (map[string]v1beta1.NeighborTemplate, error) {
+	res := map[string]v1beta1.NeighborTemplate{}
+	for _, t := range templates {
+		if !validPeerGroupName(t.Name) {
+			return nil, fmt.Errorf("invalid name %q for neighbor template", t.Name)
+		}
+		if _, ok := res[t.Name]; ok {
+			return nil, fmt.Errorf("duplicate neighbor template %s", t.Name)
+		}
+		res[t.Name] = t
+	}
+	return res, nil
+}
+
+// withTemplate returns the given neighbor with the settings it does not
+// specify taken from the template, field by field. The allowed prefixes
+// are taken from the template as a whole, and only if the neighbor does
+// not specify any.
+func withTemplate(n v1beta1.Neighbor, t v1beta1.NeighborTemplate) v1beta1.Neighbor {
+	if n.ASN == 0 && n.DynamicASN == "" {
+		n.ASN = t.ASN
+		n.DynamicASN = t.DynamicASN
+	}
+	if n.PasswordSecret.Name == "" {
+		n.PasswordSecret = t.PasswordSecret
+	}
+	if n.HoldTime.Duration == 0 && n.KeepaliveTime.Duration == 0 {
+		n.HoldTime = t.HoldTime
+		n.KeepaliveTime = t.KeepaliveTime
+	}
+	if n.BFDProfile == "" {
+		n.BFDProfile = t.BFDProfile
+	}
+	if n.EBGPMultiHop == nil {
+		multiHop := t.EBGPMultiHop
+		n.EBGPMultiHop = &multiHop
+	}
+	n.ToAdvertise = advertiseWithTemplate(n.ToAdvertise, t.ToAdvertise)
+	if isEmptyAllowed(n.ToReceive.Allowed) {
+		n.ToReceive.Allowed = t.ToReceive.Allowed
+	}
+	return n
+}
+
+// advertiseWithTemplate returns the given advertise with the settings it
+// does not specify taken from the template one.
+func advertiseWithTemplate(a, t v1beta1.Advertise) v1beta1.Advertise {
+	if isEmptyAllowed(a.Allowed) {
+		a.Allowed = t.Allowed
+	}
+	if len(a.PrefixesWithLocalPref) == 0 {
+		a.PrefixesWithLocalPref = t.PrefixesWithLocalPref
+	}
+	if len(a.PrefixesWithCommunity) == 0 {
+		a.PrefixesWithCommunity = t.PrefixesWithCommunity
+	}
+	if a.ASPathPrepend == (v1beta1.ASPathPrepend{}) {
+		a.ASPathPrepend = t.ASPathPrepend
+	}
+	if len(a.PrefixesWithASPathPrepend) == 0 {
+		a.PrefixesWithASPathPrepend = t.PrefixesWithASPathPrepend
+	}
+	if a.MED == nil {
+		a.MED = t.MED
+	}
+	if len(a.PrefixesWithMED) == 0 {
+		a.PrefixesWithMED = t.PrefixesWithMED
+	}
+	if a.Origin == "" {
+		a.Origin = t.Origin
+	}
+	if len(a.PrefixesWithOrigin) == 0 {
+		a.PrefixesWithOrigin = t.PrefixesWithOrigin
+	}
+	return a
+}
+
+// isEmptyAllowed tells if the given allowed prefixes have no settings,
+// ignoring the mode when it is the default one.
+func isEmptyAllowed(a v1beta1.AllowedPrefixes) bool {
+	if a.Mode == v1beta1.AllowRestricted {
+		a.Mode = ""
+	}
+	return reflect.DeepEqual(a, v1beta1.AllowedPrefixes{})
+}
+
+// peerGroupToFRR converts the session settings of the given template to
+// a peer group.
+func peerGroupToFRR(t v1beta1.NeighborTemplate, passwordSecrets map[string]v1.Secret) (*frr.PeerGroupConfig, error) {
+	res := &frr.PeerGroupConfig{
+		Name:       t.Name,
+		BFDProfile: t.BFDProfile,
+	}
+	var err error
+	res.HoldTime, res.KeepaliveTime, err = timersToFRR(t.HoldTime, t.KeepaliveTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timers for neighbor template %s: %w", t.Name, err)
+	}
+	if t.PasswordSecret.Name != "" {
+		res.Password, err = passwordForSecret(t.PasswordSecret, passwordSecrets)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the password for neighbor template %s: %w", t.Name, err)
+		}
+	}
+	return res, nil
+}
+
+// sortedPeerGroups returns the given peer groups sorted by name, or nil if
+// there are none.
+func sortedPeerGroups(groups map[string]*frr.PeerGroupConfig) []*frr.PeerGroupConfig {
+	if len(groups) == 0 {
+		return nil
+	}
+	res := make([]*frr.PeerGroupConfig, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, g)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// validatePeerGroups checks that the peer groups built from the templates
+// don't clash with the other neighbors of the router.
+func validatePeerGroups(groups []*frr.PeerGroupConfig, neighbors []*frr.NeighborConfig) error {
+	for _, g := range groups {
+		for _, n := range neighbors {
+			if n.Peer() == g.Name {
+				return fmt.Errorf("neighbor template %s clashes with neighbor %s", g.Name, n.Name)
+			}
+		}
+	}
+	return nil
+}
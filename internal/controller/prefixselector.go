@@ -0,0 +1,86 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"net"
+
+	v1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/internal/frr"
+	"github.com/metallb/frrk8s/internal/ipfamily"
+)
+
+// prefixSelectorToFRR validates the given selector and converts it to
+// an incoming filter. The same constraints FRR applies to the prefix-list
+// entries are enforced, so that len < ge <= le <= the family's max length.
+func prefixSelectorToFRR(s v1beta1.PrefixSelector) (frr.IncomingFilter, error) {
+	_, cidr, err := net.ParseCIDR(s.Prefix)
+	if err != nil {
+		return frr.IncomingFilter{}, fmt.Errorf("invalid prefix %s: %w", s.Prefix, err)
+	}
+	ones, bits := cidr.Mask.Size()
+	if s.GE > uint32(bits) || s.LE > uint32(bits) {
+		return frr.IncomingFilter{}, fmt.Errorf("invalid selector for %s, ge %d and le %d must not exceed %d", s.Prefix, s.GE, s.LE, bits)
+	}
+	if s.GE != 0 && s.GE <= uint32(ones) {
+		return frr.IncomingFilter{}, fmt.Errorf("invalid selector for %s, ge %d must be greater than the prefix length", s.Prefix, s.GE)
+	}
+	if s.LE != 0 && s.LE < uint32(ones) {
+		return frr.IncomingFilter{}, fmt.Errorf("invalid selector for %s, le %d must not be lower than the prefix length", s.Prefix, s.LE)
+	}
+	if s.LE != 0 && s.GE > s.LE {
+		return frr.IncomingFilter{}, fmt.Errorf("invalid selector for %s, ge %d must not be greater than le %d", s.Prefix, s.GE, s.LE)
+	}
+
+	return frr.IncomingFilter{
+		IPFamily: ipfamily.ForCIDR(cidr),
+		Prefix:   cidr.String(),
+		GE:       s.GE,
+		LE:       s.LE,
+	}, nil
+}
+
+// selectorMatches tells if the given prefix is matched by the selector,
+// with the same semantic of a FRR prefix-list entry.
+func selectorMatches(selector frr.IncomingFilter, prefix string) bool {
+	_, selectorCIDR, err := net.ParseCIDR(selector.Prefix)
+	if err != nil {
+		return false
+	}
+	_, cidr, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return false
+	}
+	if ipfamily.ForCIDR(cidr) != selector.IPFamily || !selectorCIDR.Contains(cidr.IP) {
+		return false
+	}
+
+	selectorOnes, bits := selectorCIDR.Mask.Size()
+	ones, _ := cidr.Mask.Size()
+	minLength, maxLength := uint32(selectorOnes), uint32(selectorOnes)
+	if selector.GE != 0 {
+		minLength = selector.GE
+		maxLength = uint32(bits)
+	}
+	if selector.LE != 0 {
+		maxLength = selector.LE
+	}
+	return uint32(ones) >= minLength && uint32(ones) <= maxLength
+}
+
+// routerPrefixesMatching returns the prefixes of the router matched by
+// the given selector.
+func routerPrefixesMatching(selector frr.IncomingFilter, ipv4Prefixes, ipv6Prefixes []string) []string {
+	prefixes := ipv4Prefixes
+	if selector.IPFamily == ipfamily.IPv6 {
+		prefixes = ipv6Prefixes
+	}
+	res := make([]string, 0)
+	for _, p := range prefixes {
+		if selectorMatches(selector, p) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
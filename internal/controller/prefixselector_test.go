@@ -0,0 +1,82 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/metallb/frrk8s/internal/frr"
+	"github.com/metallb/frrk8s/internal/ipfamily"
+)
+
+func TestSelectorMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector frr.IncomingFilter
+		prefix   string
+		expected bool
+	}{
+		{
+			name:     "exact match",
+			selector: frr.IncomingFilter{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8"},
+			prefix:   "10.0.0.0/8",
+			expected: true,
+		},
+		{
+			name:     "longer prefix without ge and le",
+			selector: frr.IncomingFilter{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8"},
+			prefix:   "10.1.0.0/16",
+			expected: false,
+		},
+		{
+			name:     "ge only, matching up to the max length",
+			selector: frr.IncomingFilter{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8", GE: 24},
+			prefix:   "10.1.2.3/32",
+			expected: true,
+		},
+		{
+			name:     "ge only, shorter prefix",
+			selector: frr.IncomingFilter{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8", GE: 24},
+			prefix:   "10.1.0.0/16",
+			expected: false,
+		},
+		{
+			name:     "le only, matching the selector length",
+			selector: frr.IncomingFilter{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8", LE: 16},
+			prefix:   "10.0.0.0/8",
+			expected: true,
+		},
+		{
+			name:     "le only, longer prefix",
+			selector: frr.IncomingFilter{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8", LE: 16},
+			prefix:   "10.1.2.0/24",
+			expected: false,
+		},
+		{
+			name:     "ge and le, in range",
+			selector: frr.IncomingFilter{IPFamily: ipfamily.IPv6, Prefix: "2001:db8::/32", GE: 48, LE: 64},
+			prefix:   "2001:db8:1::/56",
+			expected: true,
+		},
+		{
+			name:     "outside the selector prefix",
+			selector: frr.IncomingFilter{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8", GE: 24},
+			prefix:   "11.0.0.0/24",
+			expected: false,
+		},
+		{
+			name:     "different family",
+			selector: frr.IncomingFilter{IPFamily: ipfamily.IPv4, Prefix: "0.0.0.0/0", LE: 32},
+			prefix:   "2001:db8::/64",
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if res := selectorMatches(test.selector, test.prefix); res != test.expected {
+				t.Fatalf("expected %v, got %v", test.expected, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	v1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/internal/frr"
+	"github.com/metallb/frrk8s/internal/ipfamily"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+var redistributeTypes = sets.New(v1beta1.RedistributeConnected, v1beta1.RedistributeKernel, v1beta1.RedistributeStatic)
+
+// redistributeToFRR validates the redistributions of a router and converts
+// them sorted by type, returning nil if there are none.
+func redistributeToFRR(redistribute []v1beta1.Redistribute) ([]frr.RedistributeConfig, error) {
+	byType := map[string]frr.RedistributeConfig{}
+	for _, d := range redistribute {
+		if !redistributeTypes.Has(d.Type) {
+			return nil, fmt.Errorf("invalid type %s, must be one of %v", d.Type, sets.List(redistributeTypes))
+		}
+		if _, ok := byType[string(d.Type)]; ok {
+			return nil, fmt.Errorf("duplicate redistribution of %s routes", d.Type)
+		}
+		if d.Origin != "" && !bgpOrigins.Has(d.Origin) {
+			return nil, fmt.Errorf("invalid origin %s for %s routes, must be one of %v", d.Origin, d.Type, sets.List(bgpOrigins))
+		}
+
+		res := frr.RedistributeConfig{
+			Type:      string(d.Type),
+			Allowed:   frr.AllowedIn{All: len(d.PrefixSelectors) == 0},
+			LocalPref: d.LocalPref,
+			MED:       d.MED,
+			Origin:    d.Origin,
+		}
+		for _, s := range d.PrefixSelectors {
+			f, err := prefixSelectorToFRR(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redistribution of %s routes: %w", d.Type, err)
+			}
+			if f.IPFamily == ipfamily.IPv6 {
+				res.Allowed.PrefixesV6 = append(res.Allowed.PrefixesV6, f)
+				continue
+			}
+			res.Allowed.PrefixesV4 = append(res.Allowed.PrefixesV4, f)
+		}
+		for _, c := range d.Communities {
+			communityType, community, err := parseCommunity(c)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redistribution of %s routes: %w", d.Type, err)
+			}
+			switch communityType {
+			case standardCommunity:
+				res.Communities = append(res.Communities, community)
+			case largeCommunity:
+				res.LargeCommunities = append(res.LargeCommunities, community)
+			case extendedCommunity:
+				res.ExtendedCommunities = append(res.ExtendedCommunities, community)
+			}
+		}
+		res.Communities = sortedCommunities(res.Communities)
+		res.LargeCommunities = sortedCommunities(res.LargeCommunities)
+		res.ExtendedCommunities = sortedCommunities(res.ExtendedCommunities)
+		byType[res.Type] = res
+	}
+	return sortedRedistribute(byType), nil
+}
+
+// sortedRedistribute returns the given redistributions sorted by type, or
+// nil if there are none.
+func sortedRedistribute(byType map[string]frr.RedistributeConfig) []frr.RedistributeConfig {
+	if len(byType) == 0 {
+		return nil
+	}
+	res := make([]frr.RedistributeConfig, 0, len(byType))
+	for _, d := range byType {
+		res = append(res, d)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Type < res[j].Type
+	})
+	return res
+}
+
+// mergeRedistribute merges the redistributions of two routers. The same type
+// of routes is allowed to be redistributed by both only with the same
+// attributes, in which case the routes allowed by any of the two are.
+func mergeRedistribute(curr, toMerge []frr.RedistributeConfig) ([]frr.RedistributeConfig, error) {
+	byType := map[string]frr.RedistributeConfig{}
+	for _, d := range curr {
+		byType[d.Type] = d
+	}
+	for _, d := range toMerge {
+		existing, ok := byType[d.Type]
+		if !ok {
+			byType[d.Type] = d
+			continue
+		}
+		merged := d
+		merged.Allowed = existing.Allowed
+		if !reflect.DeepEqual(existing, merged) {
+			return nil, fmt.Errorf("different attributes for the redistributed %s routes", d.Type)
+		}
+		merged.Allowed = mergeAllowedIncoming(existing.Allowed, d.Allowed)
+		byType[d.Type] = merged
+	}
+	return sortedRedistribute(byType), nil
+}
@@ -0,0 +1,194 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	v1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/internal/frr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	reasonSucceeded    = "Succeeded"
+	reasonFailed       = "Failed"
+	reasonNotProcessed = "NotProcessed"
+	reasonNotApplied   = "NotApplied"
+	reasonPending      = "Pending"
+)
+
+// nodeStatusFor computes the status of a configuration on the given node,
+// starting from the current one (if any). pos is the position of the configuration
+// in the list of the configurations selected for the node, applyErr is the error
+// returned while translating and merging them, applied is the resulting
+// FRR configuration and reload is the outcome of the last reload of FRR.
+func nodeStatusFor(current *v1beta1.NodeStatus, nodeName string, cfg v1beta1.FRRConfiguration, pos int,
+	applyErr *configError, applied *frr.Config, reload frr.ReloadStatus) *v1beta1.NodeStatus {
+	res := &v1beta1.NodeStatus{Node: nodeName}
+	if current != nil {
+		res = current.DeepCopy()
+	}
+	res.LastError = ""
+
+	setCondition := func(conditionType string, status metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(&res.Conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			ObservedGeneration: cfg.Generation,
+		})
+	}
+
+	// The configurations are processed in order, so those following the one that
+	// failed are not translated nor merged.
+	switch {
+	case applyErr == nil || pos < applyErr.index:
+		setCondition(v1beta1.ConditionTranslated, metav1.ConditionTrue, reasonSucceeded, "")
+		setCondition(v1beta1.ConditionMerged, metav1.ConditionTrue, reasonSucceeded, "")
+	case pos == applyErr.index && applyErr.condition == v1beta1.ConditionMerged:
+		setCondition(v1beta1.ConditionTranslated, metav1.ConditionTrue, reasonSucceeded, "")
+		setCondition(v1beta1.ConditionMerged, metav1.ConditionFalse, reasonFailed, applyErr.Error())
+		res.LastError = applyErr.Error()
+	case pos == applyErr.index:
+		setCondition(v1beta1.ConditionTranslated, metav1.ConditionFalse, reasonFailed, applyErr.Error())
+		setCondition(v1beta1.ConditionMerged, metav1.ConditionUnknown, reasonNotProcessed, "")
+		res.LastError = applyErr.Error()
+	default:
+		setCondition(v1beta1.ConditionTranslated, metav1.ConditionUnknown, reasonNotProcessed, "")
+		setCondition(v1beta1.ConditionMerged, metav1.ConditionUnknown, reasonNotProcessed, "")
+	}
+
+	if applyErr != nil {
+		setCondition(v1beta1.ConditionReloaded, metav1.ConditionFalse, reasonNotApplied,
+			fmt.Sprintf("the configuration of the node was not applied: %s", applyErr))
+		return res
+	}
+
+	if reload.Config == nil || !reflect.DeepEqual(reload.Config, applied) {
+		setCondition(v1beta1.ConditionReloaded, metav1.ConditionUnknown, reasonPending, "waiting for FRR to be reloaded")
+		return res
+	}
+
+	if reload.Error != nil {
+		setCondition(v1beta1.ConditionReloaded, metav1.ConditionFalse, reasonFailed, reload.Error.Error())
+		res.LastError = reload.Error.Error()
+		return res
+	}
+
+	if reload.ReloaderFailed() {
+		message := fmt.Sprintf("frr-reload failed to apply the configuration at %s", reload.LastReloadTime.UTC().Format(time.RFC3339))
+		setCondition(v1beta1.ConditionReloaded, metav1.ConditionFalse, reasonFailed, message)
+		res.LastError = message
+		return res
+	}
+
+	setCondition(v1beta1.ConditionReloaded, metav1.ConditionTrue, reasonSucceeded, "")
+	res.LastAppliedGeneration = cfg.Generation
+	return res
+}
+
+// maxFieldManagerLength is the maximum length of the name of a field manager.
+const maxFieldManagerLength = 128
+
+// statusFieldManager returns the name of the field manager the status of the
+// given node is applied with, falling back to a hash of the node name when
+// the name would be too long.
+func statusFieldManager(nodeName string) string {
+	res := "frrk8s-" + nodeName
+	if len(res) <= maxFieldManagerLength {
+		return res
+	}
+	h := sha256.Sum256([]byte(nodeName))
+	return "frrk8s-" + hex.EncodeToString(h[:16])
+}
+
+// nodeStatusIndex returns the position of the status of the given node
+// in the list, or -1 if not found.
+func nodeStatusIndex(statuses []v1beta1.NodeStatus, nodeName string) int {
+	for i, s := range statuses {
+		if s.Node == nodeName {
+			return i
+		}
+	}
+	return -1
+}
+
+// nodeStateStatusFor converts the reload status of FRR to the status
+// of the node state.
+func nodeStateStatusFor(reload frr.ReloadStatus) v1beta1.FRRNodeStateStatus {
+	res := v1beta1.FRRNodeStateStatus{
+		RenderedConfig:   reload.RenderedConfig,
+		LastReloadResult: reload.LastReloadResult,
+		RunningConfig:    reload.RunningConfig,
+	}
+	if reload.Error != nil {
+		res.LastError = reload.Error.Error()
+	}
+	if !reload.LastReloadTime.IsZero() {
+		res.LastReloadTime = &metav1.Time{Time: reload.LastReloadTime}
+	}
+	return res
+}
+
+// bfdStatusNotAvailable is the BFD status reported for the neighbors
+// without a BFD session.
+const bfdStatusNotAvailable = "N/A"
+
+// bgpSessionStatesFor returns the states of the BGP sessions of the node,
+// keyed by the name of the related BGPSessionState. neighbors and bfdPeers
+// are grouped by vrf.
+func bgpSessionStatesFor(nodeName string, neighbors map[string][]*frr.Neighbor,
+	bfdPeers map[string][]frr.BFDPeer) map[string]v1beta1.BGPSessionStateStatus {
+	res := map[string]v1beta1.BGPSessionStateStatus{}
+	for vrf, nn := range neighbors {
+		bfdStatus := map[string]string{}
+		for _, p := range bfdPeers[vrf] {
+			bfdStatus[p.Peer] = p.Status
+		}
+
+		for _, n := range nn {
+			peer := n.Peer()
+			status := v1beta1.BGPSessionStateStatus{
+				Node:             nodeName,
+				Peer:             peer,
+				PeerGroup:        n.PeerGroup,
+				Dynamic:          n.Dynamic,
+				VRF:              vrf,
+				BGPStatus:        n.BGPState,
+				BFDStatus:        bfdStatusNotAvailable,
+				PrefixesSent:     n.PrefixSent,
+				PrefixesReceived: n.PrefixReceived,
+			}
+			// The bfd peers are always reported by address, also the
+			// ones related to unnumbered neighbors.
+			if s, ok := bfdStatus[n.IP.String()]; n.IP != nil && ok {
+				status.BFDStatus = s
+			}
+			res[bgpSessionStateName(nodeName, peer, vrf)] = status
+		}
+	}
+	return res
+}
+
+// bgpSessionStateName returns a stable name for the BGPSessionState related
+// to the given node, peer and vrf. The hash of the peer and of the vrf tells
+// the sessions of the node apart, while the node name is truncated if needed
+// to keep the name within the limits of the object names.
+func bgpSessionStateName(nodeName, peer, vrf string) string {
+	h := sha256.Sum256([]byte(vrf + "/" + peer))
+	suffix := hex.EncodeToString(h[:8])
+	prefix := nodeName
+	if maxLen := validation.DNS1123SubdomainMaxLength - len(suffix) - 1; len(prefix) > maxLen {
+		prefix = strings.TrimRight(prefix[:maxLen], ".-")
+	}
+	return fmt.Sprintf("%s-%s", prefix, suffix)
+}
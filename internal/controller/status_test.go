@@ -0,0 +1,277 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	v1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/internal/frr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestNodeStatus(t *testing.T) {
+	applied := &frr.Config{Routers: []*frr.RouterConfig{{MyASN: 65000}}}
+	cfg := v1beta1.FRRConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Namespace:  "default",
+			Generation: 3,
+		},
+	}
+
+	tests := []struct {
+		name               string
+		pos                int
+		applyErr           *configError
+		reload             frr.ReloadStatus
+		expectedConditions map[string]metav1.ConditionStatus
+		expectedGeneration int64
+		expectedError      string
+	}{
+		{
+			name:   "reloaded",
+			pos:    0,
+			reload: frr.ReloadStatus{Config: applied},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				v1beta1.ConditionTranslated: metav1.ConditionTrue,
+				v1beta1.ConditionMerged:     metav1.ConditionTrue,
+				v1beta1.ConditionReloaded:   metav1.ConditionTrue,
+			},
+			expectedGeneration: 3,
+		},
+		{
+			name:   "waiting for the reload",
+			pos:    0,
+			reload: frr.ReloadStatus{Config: &frr.Config{}},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				v1beta1.ConditionTranslated: metav1.ConditionTrue,
+				v1beta1.ConditionMerged:     metav1.ConditionTrue,
+				v1beta1.ConditionReloaded:   metav1.ConditionUnknown,
+			},
+		},
+		{
+			name:   "reload failed",
+			pos:    0,
+			reload: frr.ReloadStatus{Config: applied, Error: errors.New("reload failed")},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				v1beta1.ConditionTranslated: metav1.ConditionTrue,
+				v1beta1.ConditionMerged:     metav1.ConditionTrue,
+				v1beta1.ConditionReloaded:   metav1.ConditionFalse,
+			},
+			expectedError: "reload failed",
+		},
+		{
+			name: "rejected by the reloader",
+			pos:  0,
+			reload: frr.ReloadStatus{
+				Config:           applied,
+				AppliedTime:      time.Unix(1680000000, 500),
+				LastReloadResult: "failure",
+				LastReloadTime:   time.Unix(1680000000, 0),
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				v1beta1.ConditionTranslated: metav1.ConditionTrue,
+				v1beta1.ConditionMerged:     metav1.ConditionTrue,
+				v1beta1.ConditionReloaded:   metav1.ConditionFalse,
+			},
+			expectedError: "frr-reload failed to apply the configuration at 2023-03-28T10:40:00Z",
+		},
+		{
+			name: "reloader failure of a previous configuration",
+			pos:  0,
+			reload: frr.ReloadStatus{
+				Config:           applied,
+				AppliedTime:      time.Unix(1680000010, 0),
+				LastReloadResult: "failure",
+				LastReloadTime:   time.Unix(1680000000, 0),
+			},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				v1beta1.ConditionTranslated: metav1.ConditionTrue,
+				v1beta1.ConditionMerged:     metav1.ConditionTrue,
+				v1beta1.ConditionReloaded:   metav1.ConditionTrue,
+			},
+			expectedGeneration: 3,
+		},
+		{
+			name:     "translation failed",
+			pos:      1,
+			applyErr: &configError{index: 1, condition: v1beta1.ConditionTranslated, err: errors.New("invalid")},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				v1beta1.ConditionTranslated: metav1.ConditionFalse,
+				v1beta1.ConditionMerged:     metav1.ConditionUnknown,
+				v1beta1.ConditionReloaded:   metav1.ConditionFalse,
+			},
+			expectedError: "invalid",
+		},
+		{
+			name:     "merge failed",
+			pos:      1,
+			applyErr: &configError{index: 1, condition: v1beta1.ConditionMerged, err: errors.New("conflict")},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				v1beta1.ConditionTranslated: metav1.ConditionTrue,
+				v1beta1.ConditionMerged:     metav1.ConditionFalse,
+				v1beta1.ConditionReloaded:   metav1.ConditionFalse,
+			},
+			expectedError: "conflict",
+		},
+		{
+			name:     "processed before the failing one",
+			pos:      0,
+			applyErr: &configError{index: 1, condition: v1beta1.ConditionMerged, err: errors.New("conflict")},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				v1beta1.ConditionTranslated: metav1.ConditionTrue,
+				v1beta1.ConditionMerged:     metav1.ConditionTrue,
+				v1beta1.ConditionReloaded:   metav1.ConditionFalse,
+			},
+		},
+		{
+			name:     "processed after the failing one",
+			pos:      2,
+			applyErr: &configError{index: 1, condition: v1beta1.ConditionTranslated, err: errors.New("invalid")},
+			expectedConditions: map[string]metav1.ConditionStatus{
+				v1beta1.ConditionTranslated: metav1.ConditionUnknown,
+				v1beta1.ConditionMerged:     metav1.ConditionUnknown,
+				v1beta1.ConditionReloaded:   metav1.ConditionFalse,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status := nodeStatusFor(nil, "node", cfg, test.pos, test.applyErr, applied, test.reload)
+			if status.Node != "node" {
+				t.Fatalf("expected node %s, got %s", "node", status.Node)
+			}
+			for conditionType, expected := range test.expectedConditions {
+				c := meta.FindStatusCondition(status.Conditions, conditionType)
+				if c == nil {
+					t.Fatalf("condition %s not found", conditionType)
+				}
+				if c.Status != expected {
+					t.Fatalf("expected condition %s to be %s, got %s", conditionType, expected, c.Status)
+				}
+				if c.ObservedGeneration != cfg.Generation {
+					t.Fatalf("expected condition %s generation to be %d, got %d", conditionType, cfg.Generation, c.ObservedGeneration)
+				}
+			}
+			if status.LastAppliedGeneration != test.expectedGeneration {
+				t.Fatalf("expected last applied generation %d, got %d", test.expectedGeneration, status.LastAppliedGeneration)
+			}
+			if status.LastError != test.expectedError {
+				t.Fatalf("expected error %q, got %q", test.expectedError, status.LastError)
+			}
+		})
+	}
+}
+
+func TestNodeStateStatus(t *testing.T) {
+	reloadTime := time.Unix(1680000000, 0)
+	status := nodeStateStatusFor(frr.ReloadStatus{
+		RenderedConfig:   "rendered",
+		Error:            errors.New("failed to reload"),
+		LastReloadResult: "success",
+		LastReloadTime:   reloadTime,
+		RunningConfig:    "running",
+	})
+	expected := v1beta1.FRRNodeStateStatus{
+		RenderedConfig:   "rendered",
+		LastError:        "failed to reload",
+		LastReloadResult: "success",
+		LastReloadTime:   &metav1.Time{Time: reloadTime},
+		RunningConfig:    "running",
+	}
+	if !cmp.Equal(status, expected) {
+		t.Fatalf("unexpected status: %s", cmp.Diff(expected, status))
+	}
+
+	status = nodeStateStatusFor(frr.ReloadStatus{})
+	if status.LastReloadTime != nil {
+		t.Fatalf("expected no reload time, got %s", status.LastReloadTime)
+	}
+}
+
+func TestBGPSessionStates(t *testing.T) {
+	neighbors := map[string][]*frr.Neighbor{
+		"default": {
+			{IP: net.ParseIP("192.168.1.2"), BGPState: "Established", PrefixSent: 2, PrefixReceived: 3},
+			{IP: net.ParseIP("192.168.1.3"), BGPState: "Active"},
+			{IP: net.ParseIP("fe80::1"), Interface: "eth1", BGPState: "Established"},
+			{Interface: "eth2", BGPState: "Idle"},
+			{IP: net.ParseIP("10.1.0.5"), PeerGroup: "vms", Dynamic: true, BGPState: "Established"},
+		},
+		"red": {
+			{IP: net.ParseIP("192.168.1.2"), BGPState: "Connect"},
+		},
+	}
+	bfdPeers := map[string][]frr.BFDPeer{
+		"default": {{Peer: "192.168.1.2", Status: "up"}, {Peer: "fe80::1", Interface: "eth1", Status: "up"}},
+		"red":     {{Peer: "192.168.1.5", Status: "down"}},
+	}
+
+	states := bgpSessionStatesFor("node", neighbors, bfdPeers)
+	expected := map[string]v1beta1.BGPSessionStateStatus{
+		bgpSessionStateName("node", "192.168.1.2", "default"): {
+			Node: "node", Peer: "192.168.1.2", VRF: "default", BGPStatus: "Established", BFDStatus: "up", PrefixesSent: 2, PrefixesReceived: 3,
+		},
+		bgpSessionStateName("node", "192.168.1.3", "default"): {
+			Node: "node", Peer: "192.168.1.3", VRF: "default", BGPStatus: "Active", BFDStatus: "N/A",
+		},
+		bgpSessionStateName("node", "eth1", "default"): {
+			Node: "node", Peer: "eth1", VRF: "default", BGPStatus: "Established", BFDStatus: "up",
+		},
+		bgpSessionStateName("node", "eth2", "default"): {
+			Node: "node", Peer: "eth2", VRF: "default", BGPStatus: "Idle", BFDStatus: "N/A",
+		},
+		bgpSessionStateName("node", "10.1.0.5", "default"): {
+			Node: "node", Peer: "10.1.0.5", PeerGroup: "vms", Dynamic: true, VRF: "default", BGPStatus: "Established", BFDStatus: "N/A",
+		},
+		bgpSessionStateName("node", "192.168.1.2", "red"): {
+			Node: "node", Peer: "192.168.1.2", VRF: "red", BGPStatus: "Connect", BFDStatus: "N/A",
+		},
+	}
+	if !cmp.Equal(states, expected) {
+		t.Fatalf("unexpected states: %s", cmp.Diff(expected, states))
+	}
+}
+
+func TestBGPSessionStateName(t *testing.T) {
+	name := bgpSessionStateName("node", "192.168.1.2", "default")
+	if !strings.HasPrefix(name, "node-") {
+		t.Fatalf("expected %s to start with the node name", name)
+	}
+	if other := bgpSessionStateName("node", "192.168.1.2", "red"); other == name {
+		t.Fatalf("expected different names for different vrfs, got %s", name)
+	}
+
+	longNode := strings.Repeat("a", 235) + "." + strings.Repeat("b", 20)
+	long := bgpSessionStateName(longNode, "192.168.1.2", "default")
+	if errs := validation.IsDNS1123Subdomain(long); len(errs) > 0 {
+		t.Fatalf("invalid name %s: %v", long, errs)
+	}
+	if other := bgpSessionStateName(longNode, "192.168.1.3", "default"); other == long {
+		t.Fatalf("expected different names for different peers, got %s", long)
+	}
+}
+
+func TestStatusFieldManager(t *testing.T) {
+	if m := statusFieldManager("node"); m != "frrk8s-node" {
+		t.Fatalf("expected frrk8s-node, got %s", m)
+	}
+
+	longNode := strings.Repeat("a", 200)
+	m := statusFieldManager(longNode)
+	if len(m) > maxFieldManagerLength {
+		t.Fatalf("expected %s to be at most %d characters", m, maxFieldManagerLength)
+	}
+	if other := statusFieldManager(strings.Repeat("a", 199) + "b"); other == m {
+		t.Fatalf("expected different field managers for different nodes, got %s", m)
+	}
+}
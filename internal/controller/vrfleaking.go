@@ -0,0 +1,117 @@
+// SPDX-License-Identifier:Apache-2.0
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	v1beta1 "github.com/metallb/frrk8s/api/v1beta1"
+	"github.com/metallb/frrk8s/internal/frr"
+	"github.com/metallb/frrk8s/internal/ipfamily"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// defaultVRF is how FRR refers to the default vrf when leaking routes.
+const defaultVRF = "default"
+
+// importsToFRR validates the vrfs the given router imports the routes from,
+// and converts them sorted by vrf.
+func importsToFRR(r v1beta1.Router) ([]frr.VRFImport, error) {
+	routerVRF := r.VRF
+	if routerVRF == "" {
+		routerVRF = defaultVRF
+	}
+
+	res := make([]frr.VRFImport, 0, len(r.Imports))
+	vrfs := sets.New[string]()
+	for _, i := range r.Imports {
+		if i.VRF == "" {
+			return nil, fmt.Errorf("import with no vrf specified")
+		}
+		if i.VRF == routerVRF {
+			return nil, fmt.Errorf("vrf %s can't import from itself", routerVRF)
+		}
+		if vrfs.Has(i.VRF) {
+			return nil, fmt.Errorf("duplicate import from vrf %s", i.VRF)
+		}
+		vrfs.Insert(i.VRF)
+
+		allowed := frr.AllowedIn{All: len(i.PrefixSelectors) == 0}
+		for _, s := range i.PrefixSelectors {
+			f, err := prefixSelectorToFRR(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid import from vrf %s: %w", i.VRF, err)
+			}
+			if f.IPFamily == ipfamily.IPv6 {
+				allowed.PrefixesV6 = append(allowed.PrefixesV6, f)
+				continue
+			}
+			allowed.PrefixesV4 = append(allowed.PrefixesV4, f)
+		}
+		res = append(res, frr.VRFImport{VRF: i.VRF, Allowed: allowed})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].VRF < res[j].VRF
+	})
+	return res, nil
+}
+
+// routeTargetsToFRR validates the given route distinguisher and route targets.
+// The route distinguisher is required to export the routes.
+func routeTargetsToFRR(rt v1beta1.RouteTargets) (frr.RouteTargetsConfig, error) {
+	if err := validateRouteTargets(rt.RD, rt.Import, rt.Export); err != nil {
+		return frr.RouteTargetsConfig{}, err
+	}
+	if len(rt.Export) > 0 && rt.RD == "" {
+		return frr.RouteTargetsConfig{}, fmt.Errorf("a route distinguisher is required to export the routes")
+	}
+	return frr.RouteTargetsConfig{
+		RD:     rt.RD,
+		Import: sortedCommunities(rt.Import),
+		Export: sortedCommunities(rt.Export),
+	}, nil
+}
+
+// mergeImports unions the imports of two routers, unioning the prefixes
+// allowed to be imported from the same vrf.
+func mergeImports(curr, toMerge []frr.VRFImport) []frr.VRFImport {
+	imports := map[string]frr.VRFImport{}
+	for _, i := range curr {
+		imports[i.VRF] = i
+	}
+	for _, i := range toMerge {
+		existing, ok := imports[i.VRF]
+		if ok {
+			i.Allowed = mergeAllowedIncoming(existing.Allowed, i.Allowed)
+		}
+		imports[i.VRF] = i
+	}
+
+	if len(imports) == 0 {
+		return nil
+	}
+	res := make([]frr.VRFImport, 0, len(imports))
+	for _, i := range imports {
+		res = append(res, i)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].VRF < res[j].VRF
+	})
+	return res
+}
+
+// mergeRouteTargets unions the route targets of two routers, which are
+// allowed to have different route distinguishers only if one of the two
+// is not set.
+func mergeRouteTargets(curr, toMerge frr.RouteTargetsConfig) (frr.RouteTargetsConfig, error) {
+	rd, err := mergeRD(curr.RD, toMerge.RD)
+	if err != nil {
+		return frr.RouteTargetsConfig{}, err
+	}
+	return frr.RouteTargetsConfig{
+		RD:     rd,
+		Import: sortedCommunities(append(curr.Import, toMerge.Import...)),
+		Export: sortedCommunities(append(curr.Export, toMerge.Export...)),
+	}, nil
+}
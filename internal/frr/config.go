@@ -10,6 +10,7 @@ import (
 	"os"
 	"reflect"
 	"strconv"
+	"strings"
 	"syscall"
 	"text/template"
 	"time"
@@ -33,6 +34,10 @@ type Config struct {
 	Routers     []*RouterConfig
 	BFDProfiles []BFDProfile
 	ExtraConfig string
+	// GracefulShutdown makes the routers advertise their routes with the
+	// GRACEFUL_SHUTDOWN community, so that the peers divert the traffic
+	// before the sessions are closed.
+	GracefulShutdown bool
 }
 
 type reloadEvent struct {
@@ -47,6 +52,173 @@ type RouterConfig struct {
 	VRF          string
 	IPV4Prefixes []string
 	IPV6Prefixes []string
+	// PeerGroups are the groups the neighbors of the router inherit
+	// their session settings from.
+	PeerGroups      []*PeerGroupConfig
+	GracefulRestart GracefulRestartConfig
+	// Imports are the vrfs the routes are leaked from into the vrf of
+	// the router.
+	Imports      []VRFImport
+	RouteTargets RouteTargetsConfig
+	EVPN         EVPNConfig
+	Multipath    MultipathConfig
+	// AggregatesV4 and AggregatesV6 are the aggregates announced in the
+	// ipv4 and in the ipv6 unicast address families.
+	AggregatesV4 []AggregateConfig
+	AggregatesV6 []AggregateConfig
+	// Redistribute are the kinds of routes of the node redistributed
+	// into BGP, sorted by type.
+	Redistribute []RedistributeConfig
+}
+
+// RedistributeConfig holds the redistribution of the routes of a given type,
+// together with the attributes set on them.
+type RedistributeConfig struct {
+	// Type is either connected, kernel or static.
+	Type string
+	// Allowed are the routes allowed to be redistributed, per ip family.
+	Allowed   AllowedIn
+	LocalPref uint32
+	// MED is the multi-exit discriminator, nil meaning unset.
+	MED              *uint32
+	Origin           string
+	Communities      []string
+	LargeCommunities []string
+	// ExtendedCommunities are in the type:value form (i.e. rt:65000:100).
+	ExtendedCommunities []string
+}
+
+// HasFamily tells if any of the routes of the given ip family is
+// redistributed.
+func (r RedistributeConfig) HasFamily(family ipfamily.Family) bool {
+	if r.Allowed.All {
+		return true
+	}
+	if family == ipfamily.IPv6 {
+		return len(r.Allowed.PrefixesV6) > 0
+	}
+	return len(r.Allowed.PrefixesV4) > 0
+}
+
+// AggregateConfig holds an aggregate-address of a router.
+type AggregateConfig struct {
+	Prefix      string
+	SummaryOnly bool
+	ASSet       bool
+}
+
+// MultipathConfig holds the equal cost multipath settings of a router, with
+// 0 meaning FRR's default for the maximum paths.
+type MultipathConfig struct {
+	MaximumPathsEBGP     uint32
+	MaximumPathsIBGP     uint32
+	ASPathMultipathRelax bool
+}
+
+// HasMaximumPaths tells if the maximum paths must be configured in the
+// unicast address families.
+func (m MultipathConfig) HasMaximumPaths() bool {
+	return m.MaximumPathsEBGP != 0 || m.MaximumPathsIBGP != 0
+}
+
+// EVPNConfig holds the settings of the l2vpn evpn address family.
+type EVPNConfig struct {
+	AdvertiseAllVNI      bool
+	VNIs                 []VNIConfig
+	AdvertiseIPv4Unicast bool
+	AdvertiseIPv6Unicast bool
+	RD                   string
+	ImportRTs            []string
+	ExportRTs            []string
+}
+
+// VNIConfig holds the route distinguisher and the route targets of a VNI.
+type VNIConfig struct {
+	VNI       uint32
+	RD        string
+	ImportRTs []string
+	ExportRTs []string
+}
+
+// Enabled tells if the l2vpn evpn address family must be configured.
+func (e EVPNConfig) Enabled() bool {
+	return e.ActivatesNeighbors() || e.AdvertiseIPv4Unicast || e.AdvertiseIPv6Unicast ||
+		e.RD != "" || len(e.ImportRTs) > 0 || len(e.ExportRTs) > 0
+}
+
+// ActivatesNeighbors tells if the neighbors of the router must be activated
+// in the l2vpn evpn address family, which happens when the router
+// advertises the VNIs.
+func (e EVPNConfig) ActivatesNeighbors() bool {
+	return e.AdvertiseAllVNI || len(e.VNIs) > 0
+}
+
+// VRFImport holds the routes to leak from the given vrf.
+type VRFImport struct {
+	VRF     string
+	Allowed AllowedIn
+}
+
+// RouteTargetsConfig holds the route distinguisher and the route targets
+// used to leak the routes via the VPN tables.
+type RouteTargetsConfig struct {
+	RD     string
+	Import []string
+	Export []string
+}
+
+// HasLeaking tells if the router leaks routes from or to the other vrfs.
+func (r *RouterConfig) HasLeaking() bool {
+	return len(r.Imports) > 0 || len(r.RouteTargets.Import) > 0 || len(r.RouteTargets.Export) > 0
+}
+
+// HasRedistribution tells if any of the routes of the given ip family
+// is redistributed by the router.
+func (r *RouterConfig) HasRedistribution(family ipfamily.Family) bool {
+	for _, d := range r.Redistribute {
+		if d.HasFamily(family) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFilteredImports tells if any of the imports of the router does not
+// accept all the routes of its vrf, and so the imports must be filtered.
+func (r *RouterConfig) HasFilteredImports() bool {
+	for _, i := range r.Imports {
+		if !i.Allowed.All {
+			return true
+		}
+	}
+	return false
+}
+
+// VRFOrDefault returns the vrf of the router, as named by FRR.
+func (r *RouterConfig) VRFOrDefault() string {
+	if r.VRF == "" {
+		return "default"
+	}
+	return r.VRF
+}
+
+// GracefulRestartConfig holds the graceful restart settings of a router,
+// with the times in seconds and 0 meaning FRR's default.
+type GracefulRestartConfig struct {
+	// Mode is either restart, helper or disabled, empty meaning FRR's default.
+	Mode          string
+	RestartTime   uint64
+	StalePathTime uint64
+}
+
+// PeerGroupConfig holds the session settings shared by the neighbors
+// built from the same template.
+type PeerGroupConfig struct {
+	Name          string
+	HoldTime      uint64
+	KeepaliveTime uint64
+	Password      string
+	BFDProfile    string
 }
 
 type BFDProfile struct {
@@ -61,35 +233,167 @@ type BFDProfile struct {
 }
 
 type NeighborConfig struct {
-	IPFamily            ipfamily.Family
-	Name                string
-	ASN                 uint32
-	SrcAddr             string
-	Addr                string
+	IPFamily ipfamily.Family
+	Name     string
+	ASN      uint32
+	// DynamicASN is either internal or external, and is used in place
+	// of ASN when set.
+	DynamicASN string
+	SrcAddr    string
+	Addr       string
+	// Iface is the interface of an unnumbered neighbor, used in place of Addr.
+	Iface string
+	// PeerGroup is the name of the peer group of a dynamic neighbor, used
+	// in place of Addr. The sessions opened from any address in ListenRanges
+	// are accepted.
+	PeerGroup    string
+	ListenRanges []string
+	// Template is the peer group the neighbor is a member of, if any.
+	// The settings the neighbor shares with it are not repeated.
+	Template            *PeerGroupConfig
 	Port                uint16
 	HoldTime            uint64
 	KeepaliveTime       uint64
 	Password            string
 	Advertisements      []*AdvertisementConfig
+	Incoming            AllowedIn
 	BFDProfile          string
 	EBGPMultiHop        bool
 	VRFName             string
 	HasV4Advertisements bool
 	HasV6Advertisements bool
+	// GracefulRestart is the graceful restart mode of the neighbor,
+	// overriding the one of the router when set.
+	GracefulRestart string
+	// MaximumPrefixV4 and MaximumPrefixV6 are the limits of the prefixes
+	// accepted from the neighbor for each ip family, if any.
+	MaximumPrefixV4 *MaximumPrefixConfig
+	MaximumPrefixV6 *MaximumPrefixConfig
+	// AdvertiseRedistributed tells if the routes redistributed by the
+	// router are advertised to the neighbor.
+	AdvertiseRedistributed bool
+}
+
+// MaximumPrefixConfig is the limit of the prefixes accepted from a neighbor
+// for an address family.
+type MaximumPrefixConfig struct {
+	Limit       uint32
+	WarningOnly bool
+	// Restart is the time in minutes after which the session closed
+	// because of the limit is established again, 0 meaning never.
+	Restart uint64
 }
 
 func (n *NeighborConfig) ID() string {
 	if n.VRFName == "" {
-		return n.Addr
+		return n.Peer()
+	}
+	return fmt.Sprintf("%s-%s", n.Peer(), n.VRFName)
+}
+
+// Peer returns how the neighbor is referred to in the FRR configuration,
+// that is its peer group for dynamic neighbors, its interface for unnumbered
+// neighbors and its address otherwise.
+func (n *NeighborConfig) Peer() string {
+	if n.PeerGroup != "" {
+		return n.PeerGroup
+	}
+	if n.Iface != "" {
+		return n.Iface
+	}
+	return n.Addr
+}
+
+// RemoteAS returns the remote-as value of the neighbor.
+func (n *NeighborConfig) RemoteAS() string {
+	if n.DynamicASN != "" {
+		return n.DynamicASN
+	}
+	return strconv.FormatUint(uint64(n.ASN), 10)
+}
+
+// InheritedSettings tells which of the session settings of a neighbor
+// are inherited from its peer group. The multihop is always set on the
+// neighbor, as FRR does not allow a member to disable it when enabled
+// on the peer group.
+type InheritedSettings struct {
+	Timers     bool
+	Password   bool
+	BFDProfile bool
+}
+
+// Inherited returns the session settings the neighbor inherits from its
+// template, which are the ones equal to the template's.
+func (n *NeighborConfig) Inherited() InheritedSettings {
+	t := n.Template
+	if t == nil {
+		return InheritedSettings{}
+	}
+	return InheritedSettings{
+		Timers:     t.HoldTime != 0 && t.HoldTime == n.HoldTime && t.KeepaliveTime == n.KeepaliveTime,
+		Password:   t.Password != "" && t.Password == n.Password,
+		BFDProfile: t.BFDProfile != "" && t.BFDProfile == n.BFDProfile,
 	}
-	return fmt.Sprintf("%s-%s", n.Addr, n.VRFName)
 }
 
 type AdvertisementConfig struct {
-	IPFamily    ipfamily.Family
-	Prefix      string
-	Communities []string
-	LocalPref   uint32
+	IPFamily         ipfamily.Family
+	Prefix           string
+	Communities      []string
+	LargeCommunities []string
+	// ExtendedCommunities are in the type:value form (i.e. rt:65000:100).
+	ExtendedCommunities []string
+	LocalPref           uint32
+	ASPathPrepend       ASPathPrepend
+	// MED is the multi-exit discriminator, nil meaning unset.
+	MED    *uint32
+	Origin string
+}
+
+// ASPathPrepend is the AS number prepended Times times to the AS path
+// of an advertisement, 0 times meaning no prepending.
+type ASPathPrepend struct {
+	ASN   uint32
+	Times int
+}
+
+// String returns the list of AS numbers to prepend, as expected
+// by "set as-path prepend".
+func (a ASPathPrepend) String() string {
+	asns := make([]string, a.Times)
+	for i := range asns {
+		asns[i] = strconv.FormatUint(uint64(a.ASN), 10)
+	}
+	return strings.Join(asns, " ")
+}
+
+// AllowedIn holds the prefixes a neighbor is allowed to send.
+type AllowedIn struct {
+	// All means every prefix sent by the neighbor is accepted.
+	All        bool
+	PrefixesV4 []IncomingFilter
+	PrefixesV6 []IncomingFilter
+}
+
+type IncomingFilter struct {
+	IPFamily ipfamily.Family
+	Prefix   string
+	// LE and GE restrict the length of the prefixes matched within
+	// Prefix, 0 meaning unset.
+	LE uint32
+	GE uint32
+}
+
+// Matcher returns the prefix-list entry matching the filter.
+func (i IncomingFilter) Matcher() string {
+	res := i.Prefix
+	if i.GE > 0 {
+		res += fmt.Sprintf(" ge %d", i.GE)
+	}
+	if i.LE > 0 {
+		res += fmt.Sprintf(" le %d", i.LE)
+	}
+	return res
 }
 
 // templateConfig uses the template library to template
@@ -119,9 +423,51 @@ func templateConfig(data interface{}) (string, error) {
 			"communityPrefixList": func(neighbor *NeighborConfig, community string) string {
 				return fmt.Sprintf("%s-%s-%s-community-prefixes", neighbor.ID(), community, neighbor.IPFamily)
 			},
+			"asPathPrependPrefixList": func(neighbor *NeighborConfig, prepend ASPathPrepend) string {
+				return fmt.Sprintf("%s-%dx%d-%s-aspath-prefixes", neighbor.ID(), prepend.ASN, prepend.Times, neighbor.IPFamily)
+			},
+			"medPrefixList": func(neighbor *NeighborConfig, med uint32) string {
+				return fmt.Sprintf("%s-%d-%s-med-prefixes", neighbor.ID(), med, neighbor.IPFamily)
+			},
+			"originPrefixList": func(neighbor *NeighborConfig, origin string) string {
+				return fmt.Sprintf("%s-%s-%s-origin-prefixes", neighbor.ID(), origin, neighbor.IPFamily)
+			},
+			"largeCommunityPrefixList": func(neighbor *NeighborConfig, community string) string {
+				return fmt.Sprintf("%s-large:%s-%s-community-prefixes", neighbor.ID(), community, neighbor.IPFamily)
+			},
+			"extendedCommunity": func(community string) string {
+				return strings.Replace(community, ":", " ", 1)
+			},
 			"allowedPrefixList": func(neighbor *NeighborConfig) string {
 				return fmt.Sprintf("%s-pl-%s", neighbor.ID(), neighbor.IPFamily)
 			},
+			"allowedIncomingList": func(neighbor *NeighborConfig, ipFamily ipfamily.Family) string {
+				return fmt.Sprintf("%s-inpl-%s", neighbor.ID(), ipFamily)
+			},
+			"importPrefixList": func(router *RouterConfig, vrf string, ipFamily ipfamily.Family) string {
+				return fmt.Sprintf("%s-%s-import-%s", router.VRFOrDefault(), vrf, ipFamily)
+			},
+			"importRouteMap": func(router *RouterConfig) string {
+				return fmt.Sprintf("%s-import", router.VRFOrDefault())
+			},
+			"join": func(values []string) string {
+				return strings.Join(values, " ")
+			},
+			"redistributeRouteMap": func(router *RouterConfig, redistributeType string) string {
+				return fmt.Sprintf("%s-redistribute-%s", router.VRFOrDefault(), redistributeType)
+			},
+			"redistributePrefixList": func(router *RouterConfig, redistributeType string, ipFamily ipfamily.Family) string {
+				return fmt.Sprintf("%s-redistribute-%s-%s", router.VRFOrDefault(), redistributeType, ipFamily)
+			},
+			"extendedCommunities": func(communities []string, communityType string) string {
+				values := []string{}
+				for _, c := range communities {
+					if strings.HasPrefix(c, communityType+":") {
+						values = append(values, strings.TrimPrefix(c, communityType+":"))
+					}
+				}
+				return strings.Join(values, " ")
+			},
 			"mustDisableConnectedCheck": func(ipFamily ipfamily.Family, myASN, asn uint32, eBGPMultiHop bool) bool {
 				// return true only for IPv6 eBGP sessions
 				if ipFamily == "ipv6" && myASN != asn && !eBGPMultiHop {
@@ -189,7 +535,10 @@ var reloadConfig = func() error {
 // generateAndReloadConfigFile takes a 'struct Config' and, using a template,
 // generates and writes a valid FRR configuration file. If this completes
 // successfully it will also force FRR to reload that configuration file.
-func generateAndReloadConfigFile(config *Config, l log.Logger) error {
+// The generated configuration is returned, even when the reload fails.
+// Since the configuration carries the neighbor passwords, only the rendered
+// one with the passwords redacted is logged.
+func generateAndReloadConfigFile(config *Config, l log.Logger) (string, error) {
 	filename, found := os.LookupEnv("FRR_CONFIG_FILE")
 	if found {
 		configFileName = filename
@@ -197,21 +546,21 @@ func generateAndReloadConfigFile(config *Config, l log.Logger) error {
 
 	configString, err := templateConfig(config)
 	if err != nil {
-		level.Error(l).Log("op", "reload", "error", err, "cause", "template", "config", config)
-		return err
+		level.Error(l).Log("op", "reload", "error", err, "cause", "template")
+		return "", err
 	}
 	err = writeConfig(configString, configFileName)
 	if err != nil {
-		level.Error(l).Log("op", "reload", "error", err, "cause", "writeConfig", "config", config)
-		return err
+		level.Error(l).Log("op", "reload", "error", err, "cause", "writeConfig", "config", redactPasswords(configString))
+		return configString, err
 	}
 
 	err = reloadConfig()
 	if err != nil {
-		level.Error(l).Log("op", "reload", "error", err, "cause", "reload", "config", config)
-		return err
+		level.Error(l).Log("op", "reload", "error", err, "cause", "reload", "config", redactPasswords(configString))
+		return configString, err
 	}
-	return nil
+	return configString, nil
 }
 
 // debouncer takes a function that processes an Config, a channel where
@@ -6,6 +6,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,11 +20,51 @@ import (
 
 type ConfigHandler interface {
 	ApplyConfig(config *Config) error
+	StatusFetcher
+}
+
+type StatusFetcher interface {
+	ReloadStatus() ReloadStatus
+}
+
+// ReloadStatus represents the outcome of the last attempt to reload FRR.
+type ReloadStatus struct {
+	// Config is the configuration FRR was last reloaded with.
+	Config *Config
+	// RenderedConfig is the configuration file generated from Config,
+	// with the passwords redacted.
+	RenderedConfig string
+	// Error is the error that occurred while reloading the configuration, if any.
+	Error error
+	// LastReloadResult is the result of the last reload as reported by
+	// the reloader, either "success" or "failure".
+	LastReloadResult string
+	// LastReloadTime is the time of the last reload as reported by the reloader.
+	LastReloadTime time.Time
+	// RunningConfig is the running configuration of FRR collected by the
+	// reloader after the last reload, with the passwords redacted.
+	RunningConfig string
+	// AppliedTime is the time FRR was first reloaded with Config, as the
+	// reload is retried with the same configuration after a failure.
+	AppliedTime time.Time
+}
+
+// ReloaderFailed tells if the reloader failed to apply Config, which is
+// when it reports a failure not older than AppliedTime. The time reported
+// by the reloader has the precision of seconds.
+func (s ReloadStatus) ReloaderFailed() bool {
+	if s.Config == nil || s.LastReloadResult != "failure" {
+		return false
+	}
+	return !s.LastReloadTime.Before(s.AppliedTime.Truncate(time.Second))
 }
 
 type FRR struct {
-	reloadConfig chan reloadEvent
-	logLevel     string
+	reloadConfig    chan reloadEvent
+	logLevel        string
+	reloadStatus    ReloadStatus
+	onStatusChanged func()
+	logger          log.Logger
 	sync.Mutex
 }
 
@@ -42,62 +85,186 @@ func (f *FRR) ApplyConfig(config *Config) error {
 	return nil
 }
 
+// ReloadStatus returns the outcome of the last attempt to reload FRR.
+func (f *FRR) ReloadStatus() ReloadStatus {
+	f.Lock()
+	defer f.Unlock()
+	return f.reloadStatus
+}
+
+func (f *FRR) updateReloadStatus(config *Config, renderedConfig string, err error) {
+	f.Lock()
+	if !reflect.DeepEqual(f.reloadStatus.Config, config) {
+		f.reloadStatus.AppliedTime = time.Now()
+	}
+	f.reloadStatus.Config = config
+	f.reloadStatus.RenderedConfig = redactPasswords(renderedConfig)
+	f.reloadStatus.Error = err
+	f.Unlock()
+	f.onStatusChanged()
+}
+
+func (f *FRR) updateReloaderResult(result reloaderResult) {
+	f.Lock()
+	f.reloadStatus.LastReloadResult = result.status
+	f.reloadStatus.LastReloadTime = result.time
+	f.reloadStatus.RunningConfig = redactPasswords(result.runningConfig)
+	f.Unlock()
+	f.onStatusChanged()
+}
+
 var debounceTimeout = 3 * time.Second
 var failureTimeout = time.Second * 5
 
-func NewFRR(ctx context.Context, logger log.Logger, logLevel logging.Level) *FRR {
+// NewFRR returns a new FRR config handler. onStatusChanged is invoked
+// every time FRR is reloaded and every time the reloader reports a new
+// result, after the reload status is updated.
+func NewFRR(ctx context.Context, onStatusChanged func(), logger log.Logger, logLevel logging.Level) *FRR {
 	res := &FRR{
-		reloadConfig: make(chan reloadEvent),
-		logLevel:     logLevelToFRR(logLevel),
+		reloadConfig:    make(chan reloadEvent),
+		logLevel:        logLevelToFRR(logLevel),
+		onStatusChanged: onStatusChanged,
+		logger:          logger,
 	}
 	reload := func(config *Config) error {
-		return generateAndReloadConfigFile(config, logger)
+		renderedConfig, err := generateAndReloadConfigFile(config, logger)
+		res.updateReloadStatus(config, renderedConfig, err)
+		return err
 	}
 
 	debouncer(ctx, reload, res.reloadConfig, debounceTimeout, failureTimeout, logger)
-	reloadValidator(ctx, logger, res.reloadConfig)
+	reloadValidator(ctx, logger, res.reloadConfig, res.updateReloaderResult)
 	return res
 }
 
-func reloadValidator(ctx context.Context, l log.Logger, reload chan<- reloadEvent) {
+// GracefulShutdownReloadTimeout is the maximum time GracefulShutdown waits
+// for the reloader to apply the configuration with the graceful shutdown
+// enabled.
+var GracefulShutdownReloadTimeout = 10 * time.Second
+
+var reloaderPollInterval = time.Second
+
+// GracefulShutdown reloads FRR with the last applied configuration and
+// the graceful shutdown enabled, waits for the reloader to apply it, and
+// then waits for the given time to let the peers divert the traffic away
+// from the node. It is meant to be called once the context passed to NewFRR
+// is done, so that no other reload overrides it.
+func (f *FRR) GracefulShutdown(wait time.Duration) error {
+	f.Lock()
+	applied := f.reloadStatus.Config
+	f.Unlock()
+	if applied == nil {
+		return nil
+	}
+
+	config := *applied
+	config.GracefulShutdown = true
+	prevTimeStamp, _, _ := readReloaderStatus()
+	_, err := generateAndReloadConfigFile(&config, f.logger)
+	if err != nil {
+		return err
+	}
+	err = waitForReloader(prevTimeStamp, GracefulShutdownReloadTimeout)
+	if err != nil {
+		return err
+	}
+	level.Info(f.logger).Log("op", "graceful-shutdown", "wait", wait)
+	time.Sleep(wait)
+	return nil
+}
+
+// waitForReloader waits for the reloader to report the outcome of a reload
+// more recent than the one with the given timestamp, failing if the reload
+// is not successful or if nothing is reported within the given timeout.
+func waitForReloader(prevTimeStamp string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		timeStamp, status, err := readReloaderStatus()
+		if err == nil && timeStamp != prevTimeStamp {
+			if status != "success" {
+				return fmt.Errorf("the reloader failed to apply the configuration, status %s", status)
+			}
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for the reloader to apply the configuration")
+		}
+		time.Sleep(reloaderPollInterval)
+	}
+}
+
+// readReloaderStatus returns the timestamp and the status of the last
+// reload, as reported by the reloader in the status file.
+func readReloaderStatus() (string, string, error) {
+	bytes, err := os.ReadFile(statusFileName)
+	if err != nil {
+		return "", "", err
+	}
+	fields := strings.Fields(string(bytes))
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("unexpected content of %s: %q", statusFileName, string(bytes))
+	}
+	return fields[0], fields[1], nil
+}
+
+func reloadValidator(ctx context.Context, l log.Logger, reload chan<- reloadEvent, onResult func(reloaderResult)) {
 	var tickerIntervals = 30 * time.Second
 	var prevReloadTimeStamp string
 
 	ticker := time.NewTicker(tickerIntervals)
 	go func() {
-		select {
-		case <-ticker.C:
-			validateReload(l, &prevReloadTimeStamp, reload)
-		case <-ctx.Done():
-			return
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				validateReload(l, &prevReloadTimeStamp, reload, onResult)
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 }
 
-const statusFileName = "/etc/frr_reloader/.status"
+var (
+	statusFileName        = "/etc/frr_reloader/.status"
+	runningConfigFileName = "/etc/frr_reloader/.running-config"
+)
 
-func validateReload(l log.Logger, prevReloadTimeStamp *string, reload chan<- reloadEvent) {
-	bytes, err := os.ReadFile(statusFileName)
+// reloaderResult is the outcome of a reload as reported by the reloader.
+type reloaderResult struct {
+	time          time.Time
+	status        string
+	runningConfig string
+}
+
+func validateReload(l log.Logger, prevReloadTimeStamp *string, reload chan<- reloadEvent, onResult func(reloaderResult)) {
+	timeStamp, status, err := readReloaderStatus()
 	if err != nil {
 		if !os.IsNotExist(err) {
-			level.Error(l).Log("op", "reload-validate", "error", err, "cause", "readFile", "fileName", statusFileName)
+			level.Error(l).Log("op", "reload-validate", "error", err, "cause", "readStatus", "fileName", statusFileName)
 		}
 		return
 	}
 
-	lastReloadStatus := strings.Fields(string(bytes))
-	if len(lastReloadStatus) != 2 {
-		level.Error(l).Log("op", "reload-validate", "cause", "Fields", "bytes", string(bytes))
-		return
-	}
-
-	timeStamp, status := lastReloadStatus[0], lastReloadStatus[1]
 	if timeStamp == *prevReloadTimeStamp {
 		return
 	}
 
 	*prevReloadTimeStamp = timeStamp
 
+	result := reloaderResult{status: status}
+	if seconds, err := strconv.ParseInt(timeStamp, 10, 64); err == nil {
+		result.time = time.Unix(seconds, 0)
+	}
+	// The reloader dumps the running config before writing the status file,
+	// so the two are consistent.
+	runningConfig, err := os.ReadFile(runningConfigFileName)
+	if err != nil && !os.IsNotExist(err) {
+		level.Error(l).Log("op", "reload-validate", "error", err, "cause", "readFile", "fileName", runningConfigFileName)
+	}
+	result.runningConfig = string(runningConfig)
+	onResult(result)
+
 	if strings.Compare(status, "failure") == 0 {
 		level.Error(l).Log("op", "reload-validate", "error", fmt.Errorf("reload failure"),
 			"cause", "frr reload failed", "status", status)
@@ -108,6 +275,14 @@ func validateReload(l log.Logger, prevReloadTimeStamp *string, reload chan<- rel
 	level.Info(l).Log("op", "reload-validate", "success", "reloaded config")
 }
 
+var passwordRegex = regexp.MustCompile(`(?m)(\spassword)\s.*$`)
+
+// redactPasswords replaces the passwords contained in the given FRR
+// configuration, so that it can be exposed safely.
+func redactPasswords(config string) string {
+	return passwordRegex.ReplaceAllString(config, "$1 <retracted>")
+}
+
 func logLevelToFRR(level logging.Level) string {
 	// Allowed frr log levels are: emergencies, alerts, critical,
 	// 		errors, warnings, notifications, informational, or debugging
@@ -23,6 +23,8 @@ const testData = "testdata/"
 
 var update = flag.Bool("update", false, "update .golden files")
 
+var emptyCB = func() {}
+
 func testOsHostname() (string, error) {
 	return "dummyhostname", nil
 }
@@ -97,7 +99,7 @@ func testCheckConfigFile(t *testing.T) {
 func TestSingleSession(t *testing.T) {
 	testSetup(t)
 	ctx, cancel := context.WithCancel(context.Background())
-	frr := NewFRR(ctx, log.NewNopLogger(), logging.LevelInfo)
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
 	defer cancel()
 
 	config := Config{
@@ -132,3 +134,1005 @@ func TestSingleSession(t *testing.T) {
 
 	testCheckConfigFile(t)
 }
+
+func TestSingleSessionExtras(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+					},
+				},
+			},
+		},
+		ExtraConfig: "# foo\n# baar",
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleSessionWithTimersAndBFD(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	receiveInterval := uint32(100)
+	detectMultiplier := uint32(5)
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily:      ipfamily.IPv4,
+						ASN:           65001,
+						Addr:          "192.168.1.2",
+						HoldTime:      90,
+						KeepaliveTime: 30,
+						BFDProfile:    "fast",
+					},
+				},
+			},
+		},
+		BFDProfiles: []BFDProfile{
+			{
+				Name:             "fast",
+				ReceiveInterval:  &receiveInterval,
+				DetectMultiplier: &detectMultiplier,
+				EchoMode:         true,
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleSessionWithLocalPrefAndCommunities(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+						Advertisements: []*AdvertisementConfig{
+							{
+								IPFamily:    ipfamily.IPv4,
+								Prefix:      "192.169.1.0/24",
+								LocalPref:   200,
+								Communities: []string{"65000:100", "no-export"},
+							},
+							{
+								IPFamily:    ipfamily.IPv4,
+								Prefix:      "192.170.1.0/24",
+								Communities: []string{"no-export"},
+							},
+						},
+						HasV4Advertisements: true,
+					},
+				},
+				IPV4Prefixes: []string{"192.169.1.0/24", "192.170.1.0/24"},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleSessionWithLargeAndExtendedCommunities(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+						Advertisements: []*AdvertisementConfig{
+							{
+								IPFamily:            ipfamily.IPv4,
+								Prefix:              "192.169.1.0/24",
+								Communities:         []string{"65000:100"},
+								LargeCommunities:    []string{"4200000000:1:2"},
+								ExtendedCommunities: []string{"rt:65000:100", "soo:192.168.1.1:1"},
+							},
+						},
+						HasV4Advertisements: true,
+					},
+				},
+				IPV4Prefixes: []string{"192.169.1.0/24"},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleSessionWithASPathPrepend(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+						Advertisements: []*AdvertisementConfig{
+							{
+								IPFamily:      ipfamily.IPv4,
+								Prefix:        "192.169.1.0/24",
+								LocalPref:     200,
+								ASPathPrepend: ASPathPrepend{ASN: 65000, Times: 3},
+							},
+							{
+								IPFamily:      ipfamily.IPv4,
+								Prefix:        "192.170.1.0/24",
+								ASPathPrepend: ASPathPrepend{ASN: 65100, Times: 1},
+							},
+						},
+						HasV4Advertisements: true,
+					},
+				},
+				IPV4Prefixes: []string{"192.169.1.0/24", "192.170.1.0/24"},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleSessionWithMEDAndOrigin(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	med, zeroMED := uint32(100), uint32(0)
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+						Advertisements: []*AdvertisementConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Prefix:   "192.169.1.0/24",
+								MED:      &med,
+								Origin:   "igp",
+							},
+							{
+								IPFamily: ipfamily.IPv4,
+								Prefix:   "192.170.1.0/24",
+								MED:      &zeroMED,
+								Origin:   "incomplete",
+							},
+						},
+						HasV4Advertisements: true,
+					},
+				},
+				IPV4Prefixes: []string{"192.169.1.0/24", "192.170.1.0/24"},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleUnnumberedSession(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily:   ipfamily.DualStack,
+						DynamicASN: "external",
+						Iface:      "eth1",
+						Advertisements: []*AdvertisementConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Prefix:   "192.169.1.0/24",
+							},
+							{
+								IPFamily: ipfamily.IPv6,
+								Prefix:   "2001:db8::/64",
+							},
+						},
+						HasV4Advertisements: true,
+						HasV6Advertisements: true,
+					},
+				},
+				IPV4Prefixes: []string{"192.169.1.0/24"},
+				IPV6Prefixes: []string{"2001:db8::/64"},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestDynamicNeighbors(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+					},
+					{
+						IPFamily:      ipfamily.DualStack,
+						DynamicASN:    "external",
+						PeerGroup:     "vms",
+						ListenRanges:  []string{"10.1.0.0/24", "2001:db8:1::/64"},
+						HoldTime:      90,
+						KeepaliveTime: 30,
+						Advertisements: []*AdvertisementConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Prefix:   "192.169.1.0/24",
+							},
+						},
+						HasV4Advertisements: true,
+						Incoming: AllowedIn{
+							All: true,
+						},
+					},
+				},
+				IPV4Prefixes: []string{"192.169.1.0/24"},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestNeighborsWithTemplate(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	group := &PeerGroupConfig{
+		Name:          "tors",
+		HoldTime:      90,
+		KeepaliveTime: 30,
+		Password:      "password",
+		BFDProfile:    "fast",
+	}
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily:      ipfamily.IPv4,
+						ASN:           65001,
+						Addr:          "192.168.1.2",
+						HoldTime:      90,
+						KeepaliveTime: 30,
+						Password:      "password",
+						BFDProfile:    "fast",
+						EBGPMultiHop:  true,
+						Template:      group,
+					},
+					{
+						IPFamily:      ipfamily.IPv6,
+						ASN:           65002,
+						Addr:          "2001:db8::2",
+						HoldTime:      180,
+						KeepaliveTime: 60,
+						Password:      "password",
+						BFDProfile:    "slow",
+						EBGPMultiHop:  true,
+						Template:      group,
+						Advertisements: []*AdvertisementConfig{
+							{
+								IPFamily: ipfamily.IPv6,
+								Prefix:   "2001:db8:1::/64",
+							},
+						},
+						HasV6Advertisements: true,
+					},
+				},
+				PeerGroups:   []*PeerGroupConfig{group},
+				IPV6Prefixes: []string{"2001:db8:1::/64"},
+			},
+		},
+		BFDProfiles: []BFDProfile{
+			{
+				Name: "fast",
+			},
+			{
+				Name: "slow",
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleSessionWithGracefulRestart(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				GracefulRestart: GracefulRestartConfig{
+					Mode:          "restart",
+					RestartTime:   240,
+					StalePathTime: 360,
+				},
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+					},
+					{
+						IPFamily:        ipfamily.IPv4,
+						ASN:             65002,
+						Addr:            "192.168.1.3",
+						GracefulRestart: "disabled",
+					},
+				},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+					},
+				},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+	err = wait.PollImmediate(10*time.Millisecond, 2*time.Second, func() (bool, error) {
+		return frr.ReloadStatus().Config != nil, nil
+	})
+	if err != nil {
+		t.Fatalf("config was never applied")
+	}
+
+	cancel()
+	testFakeReloader(t, "1680000000 success")
+	err = frr.GracefulShutdown(0)
+	if err != nil {
+		t.Fatalf("Failed to enable the graceful shutdown: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestGracefulShutdownNotApplied(t *testing.T) {
+	testSetup(t)
+	timeout := GracefulShutdownReloadTimeout
+	GracefulShutdownReloadTimeout = 100 * time.Millisecond
+	t.Cleanup(func() {
+		GracefulShutdownReloadTimeout = timeout
+	})
+
+	tests := []struct {
+		name           string
+		reloaderStatus string
+		expectedErr    string
+	}{
+		{
+			name:           "reload failed",
+			reloaderStatus: "1680000000 failure",
+			expectedErr:    "the reloader failed to apply the configuration, status failure",
+		},
+		{
+			name:           "reloader not reporting",
+			reloaderStatus: "",
+			expectedErr:    "timed out waiting for the reloader to apply the configuration",
+		},
+	}
+
+	for _, test := range tests {
+		ctx, cancel := context.WithCancel(context.Background())
+		frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+
+		config := Config{
+			Routers: []*RouterConfig{
+				{
+					MyASN: 65000,
+				},
+			},
+		}
+		err := frr.ApplyConfig(&config)
+		if err != nil {
+			t.Fatalf("%s: failed to apply config: %s", test.name, err)
+		}
+		err = wait.PollImmediate(10*time.Millisecond, 2*time.Second, func() (bool, error) {
+			return frr.ReloadStatus().Config != nil, nil
+		})
+		if err != nil {
+			t.Fatalf("%s: config was never applied", test.name)
+		}
+
+		cancel()
+		testFakeReloader(t, test.reloaderStatus)
+		err = frr.GracefulShutdown(time.Hour)
+		if err == nil || err.Error() != test.expectedErr {
+			t.Fatalf("%s: expected error %q, got %v", test.name, test.expectedErr, err)
+		}
+	}
+}
+
+// testFakeReloader makes the reloads write the given status to the status
+// file, as the reloader does once it applies the configuration. An empty
+// status means that the reloader does not report anything.
+func testFakeReloader(t *testing.T, status string) {
+	prevStatusFileName, prevReloadConfig, prevPollInterval := statusFileName, reloadConfig, reloaderPollInterval
+	t.Cleanup(func() {
+		statusFileName, reloadConfig, reloaderPollInterval = prevStatusFileName, prevReloadConfig, prevPollInterval
+	})
+
+	statusFileName = filepath.Join(t.TempDir(), ".status")
+	reloaderPollInterval = 10 * time.Millisecond
+	reloadConfig = func() error {
+		if status == "" {
+			return nil
+		}
+		return os.WriteFile(statusFileName, []byte(status), 0600)
+	}
+}
+
+func TestVRFLeaking(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+						Incoming: AllowedIn{
+							All: true,
+						},
+					},
+				},
+			},
+			{
+				MyASN: 65000,
+				VRF:   "red",
+				Imports: []VRFImport{
+					{
+						VRF: "blue",
+						Allowed: AllowedIn{
+							All: true,
+						},
+					},
+					{
+						VRF: "default",
+						Allowed: AllowedIn{
+							PrefixesV4: []IncomingFilter{
+								{
+									IPFamily: ipfamily.IPv4,
+									Prefix:   "0.0.0.0/0",
+								},
+							},
+							PrefixesV6: []IncomingFilter{
+								{
+									IPFamily: ipfamily.IPv6,
+									Prefix:   "::/0",
+								},
+							},
+						},
+					},
+				},
+				IPV4Prefixes: []string{"192.169.1.0/24"},
+			},
+			{
+				MyASN: 65000,
+				VRF:   "blue",
+				RouteTargets: RouteTargetsConfig{
+					RD:     "65000:2",
+					Import: []string{"65000:100", "65000:200"},
+					Export: []string{"65000:100"},
+				},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestEVPN(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+					},
+				},
+				EVPN: EVPNConfig{
+					AdvertiseAllVNI: true,
+					VNIs: []VNIConfig{
+						{
+							VNI:       100,
+							RD:        "65000:100",
+							ImportRTs: []string{"65000:100"},
+							ExportRTs: []string{"65000:100"},
+						},
+					},
+				},
+			},
+			{
+				MyASN:        65000,
+				VRF:          "red",
+				IPV4Prefixes: []string{"192.169.1.0/24"},
+				EVPN: EVPNConfig{
+					AdvertiseIPv4Unicast: true,
+					AdvertiseIPv6Unicast: true,
+					RD:                   "65000:1",
+					ImportRTs:            []string{"65000:1"},
+					ExportRTs:            []string{"65000:1"},
+				},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleSessionWithUpdateSource(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						SrcAddr:  "192.168.1.10",
+						Addr:     "192.168.1.2",
+					},
+					{
+						IPFamily: ipfamily.IPv6,
+						ASN:      65001,
+						SrcAddr:  "eth1",
+						Addr:     "2001:db8::2",
+					},
+				},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleSessionWithMaximumPrefixes(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+						Incoming: AllowedIn{
+							All: true,
+						},
+						MaximumPrefixV4: &MaximumPrefixConfig{
+							Limit:   100,
+							Restart: 5,
+						},
+						MaximumPrefixV6: &MaximumPrefixConfig{
+							Limit:       50,
+							WarningOnly: true,
+						},
+					},
+				},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestTwoSessionsWithMultipath(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+						Incoming: AllowedIn{
+							All: true,
+						},
+					},
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65002,
+						Addr:     "192.168.1.3",
+						Incoming: AllowedIn{
+							All: true,
+						},
+					},
+				},
+				Multipath: MultipathConfig{
+					MaximumPathsEBGP:     4,
+					MaximumPathsIBGP:     2,
+					ASPathMultipathRelax: true,
+				},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleSessionWithAggregates(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+						Advertisements: []*AdvertisementConfig{
+							{
+								IPFamily: ipfamily.IPv4,
+								Prefix:   "192.169.0.0/16",
+							},
+							{
+								IPFamily: ipfamily.IPv6,
+								Prefix:   "2001:db8::/32",
+							},
+						},
+						HasV4Advertisements: true,
+						HasV6Advertisements: true,
+					},
+				},
+				IPV4Prefixes: []string{"192.169.1.1/32", "192.169.1.2/32"},
+				IPV6Prefixes: []string{"2001:db8::1/128"},
+				AggregatesV4: []AggregateConfig{
+					{
+						Prefix:      "192.169.0.0/16",
+						SummaryOnly: true,
+					},
+				},
+				AggregatesV6: []AggregateConfig{
+					{
+						Prefix:      "2001:db8::/32",
+						SummaryOnly: true,
+						ASSet:       true,
+					},
+				},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestSingleSessionWithRedistribution(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	med := uint32(50)
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily:               ipfamily.IPv4,
+						ASN:                    65001,
+						Addr:                   "192.168.1.2",
+						AdvertiseRedistributed: true,
+					},
+				},
+				Redistribute: []RedistributeConfig{
+					{
+						Type: "connected",
+						Allowed: AllowedIn{
+							PrefixesV4: []IncomingFilter{
+								{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8", GE: 24},
+								{IPFamily: ipfamily.IPv4, Prefix: "192.169.1.0/24"},
+							},
+						},
+						LocalPref:           200,
+						MED:                 &med,
+						Origin:              "igp",
+						Communities:         []string{"10:100", "10:200"},
+						LargeCommunities:    []string{"123:456:7890"},
+						ExtendedCommunities: []string{"rt:65000:100", "soo:65000:200"},
+					},
+					{
+						Type:    "kernel",
+						Allowed: AllowedIn{All: true},
+					},
+				},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestTwoSessionsWithAllowedIncoming(t *testing.T) {
+	testSetup(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	frr := NewFRR(ctx, emptyCB, log.NewNopLogger(), logging.LevelInfo)
+	defer cancel()
+
+	config := Config{
+		Routers: []*RouterConfig{
+			{
+				MyASN: 65000,
+				Neighbors: []*NeighborConfig{
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65001,
+						Addr:     "192.168.1.2",
+						Incoming: AllowedIn{
+							PrefixesV4: []IncomingFilter{
+								{IPFamily: ipfamily.IPv4, Prefix: "0.0.0.0/0"},
+								{IPFamily: ipfamily.IPv4, Prefix: "10.0.0.0/8", GE: 32},
+								{IPFamily: ipfamily.IPv4, Prefix: "192.169.1.0/24"},
+								{IPFamily: ipfamily.IPv4, Prefix: "192.170.0.0/16", GE: 24, LE: 28},
+							},
+							PrefixesV6: []IncomingFilter{
+								{IPFamily: ipfamily.IPv6, Prefix: "2001:db8::/64"},
+								{IPFamily: ipfamily.IPv6, Prefix: "2001:db9::/32", LE: 64},
+							},
+						},
+					},
+					{
+						IPFamily: ipfamily.IPv4,
+						ASN:      65002,
+						Addr:     "192.168.1.3",
+						Incoming: AllowedIn{
+							All: true,
+						},
+					},
+				},
+			},
+		},
+	}
+	err := frr.ApplyConfig(&config)
+	if err != nil {
+		t.Fatalf("Failed to apply config: %s", err)
+	}
+
+	testCheckConfigFile(t)
+}
+
+func TestValidateReload(t *testing.T) {
+	dir := t.TempDir()
+	statusFileName = filepath.Join(dir, ".status")
+	runningConfigFileName = filepath.Join(dir, ".running-config")
+
+	err := os.WriteFile(runningConfigFileName, []byte("router bgp 65000\n neighbor 192.168.1.2 password secret\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write the running config: %s", err)
+	}
+	err = os.WriteFile(statusFileName, []byte("1680000000 failure"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write the status file: %s", err)
+	}
+
+	reload := make(chan reloadEvent, 1)
+	results := []reloaderResult{}
+	onResult := func(r reloaderResult) {
+		results = append(results, r)
+	}
+
+	var prevTimeStamp string
+	validateReload(log.NewNopLogger(), &prevTimeStamp, reload, onResult)
+	validateReload(log.NewNopLogger(), &prevTimeStamp, reload, onResult)
+
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].status != "failure" {
+		t.Fatalf("expected status failure, got %s", results[0].status)
+	}
+	if !results[0].time.Equal(time.Unix(1680000000, 0)) {
+		t.Fatalf("unexpected reload time %s", results[0].time)
+	}
+	if results[0].runningConfig != "router bgp 65000\n neighbor 192.168.1.2 password secret\n" {
+		t.Fatalf("unexpected running config %q", results[0].runningConfig)
+	}
+	if len(reload) != 1 {
+		t.Fatalf("expected a reload with the old config to be requested after a failure")
+	}
+}
+
+func TestRedactPasswords(t *testing.T) {
+	config := `router bgp 65000
+ neighbor 192.168.1.2 remote-as 65001
+ neighbor 192.168.1.2 password secret
+ neighbor 192.168.1.3 password   another secret
+`
+	expected := `router bgp 65000
+ neighbor 192.168.1.2 remote-as 65001
+ neighbor 192.168.1.2 password <retracted>
+ neighbor 192.168.1.3 password <retracted>
+`
+	if res := redactPasswords(config); res != expected {
+		t.Fatalf("expected %q, got %q", expected, res)
+	}
+}
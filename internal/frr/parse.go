@@ -8,42 +8,93 @@ import (
 	"net"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
 
 type Neighbor struct {
-	IP             net.IP
+	IP net.IP
+	// Interface is the interface of an unnumbered neighbor, whose IP
+	// is the discovered link-local address, if any.
+	Interface string
+	// PeerGroup is the peer group the neighbor belongs to, and Dynamic
+	// tells if the neighbor was accepted via the listen ranges of the group.
+	PeerGroup      string
+	Dynamic        bool
 	VRF            string
 	Connected      bool
+	BGPState       string
 	LocalAS        string
 	RemoteAS       string
 	PrefixSent     int
+	PrefixReceived int
 	Port           int
 	RemoteRouterID string
 	MsgStats       MessageStats
+	// PrefixCounters contains the prefix counters of each address family
+	// of the session, indexed by the name FRR gives to the family
+	// (i.e. ipv4Unicast).
+	PrefixCounters map[string]AddressFamilyPrefixes
+}
+
+// AddressFamilyPrefixes are the prefix counters of an address family of
+// a session.
+type AddressFamilyPrefixes struct {
+	Sent     int
+	Received int
+	// MaxPrefixes is the maximum number of prefixes accepted from the
+	// neighbor, 0 if not limited.
+	MaxPrefixes int
 }
 
 type Route struct {
 	Destination *net.IPNet
 	NextHops    []net.IP
-	LocalPref   uint32
-	Origin      string
+	// LocalPref, Origin, MED and ASPath are the attributes of the best path.
+	LocalPref uint32
+	Origin    string
+	MED       uint32
+	ASPath    string
+	// Paths are all the paths of the route, in the order FRR returns them.
+	// The multipath ones are installed together with the best one.
+	Paths []RoutePath
+}
+
+// RoutePath is a single path of a route, i.e. the one received from
+// a given peer.
+type RoutePath struct {
+	PeerID    string
+	NextHops  []net.IP
+	LocalPref uint32
+	Origin    string
+	MED       uint32
+	ASPath    string
+	Valid     bool
+	BestPath  bool
+	Multipath bool
 }
 
 const bgpConnected = "Established"
 
 type FRRNeighbor struct {
-	RemoteAs          int          `json:"remoteAs"`
-	LocalAs           int          `json:"localAs"`
-	RemoteRouterID    string       `json:"remoteRouterId"`
-	BgpVersion        int          `json:"bgpVersion"`
-	BgpState          string       `json:"bgpState"`
-	PortForeign       int          `json:"portForeign"`
-	MsgStats          MessageStats `json:"messageStats"`
-	VRFName           string       `json:"vrf"`
+	RemoteAs       int          `json:"remoteAs"`
+	LocalAs        int          `json:"localAs"`
+	RemoteRouterID string       `json:"remoteRouterId"`
+	BgpVersion     int          `json:"bgpVersion"`
+	BgpState       string       `json:"bgpState"`
+	PortForeign    int          `json:"portForeign"`
+	MsgStats       MessageStats `json:"messageStats"`
+	VRFName        string       `json:"vrf"`
+	// NeighborAddr is the address of an unnumbered neighbor, set only
+	// when it has been discovered.
+	NeighborAddr      string `json:"bgpNeighborAddr"`
+	PeerGroup         string `json:"peerGroup"`
+	DynamicPeer       bool   `json:"dynamicPeer"`
 	AddressFamilyInfo map[string]struct {
-		SentPrefixCounter int `json:"sentPrefixCounter"`
+		SentPrefixCounter     int `json:"sentPrefixCounter"`
+		AcceptedPrefixCounter int `json:"acceptedPrefixCounter"`
+		PrefixAllowedMax      int `json:"prefixAllowedMax"`
 	} `json:"addressFamilyInfo"`
 }
 
@@ -69,6 +120,10 @@ type FRRRoute struct {
 	PeerID    string `json:"peerId"`
 	LocalPref uint32 `json:"locPrf"`
 	Origin    string `json:"origin"`
+	MED       uint32 `json:"metric"`
+	Path      string `json:"path"`
+	BestPath  bool   `json:"bestpath"`
+	Multipath bool   `json:"multipath"`
 	Nexthops  []struct {
 		IP    string `json:"ip"`
 		Scope string `json:"scope"`
@@ -115,28 +170,7 @@ func ParseNeighbour(vtyshRes string) (*Neighbor, error) {
 		return nil, errors.New("no peers were returned")
 	}
 	for k, n := range res {
-		ip := net.ParseIP(k)
-		if ip == nil {
-			return nil, fmt.Errorf("failed to parse %s as ip", ip)
-		}
-		connected := true
-		if n.BgpState != bgpConnected {
-			connected = false
-		}
-		prefixSent := 0
-		for _, s := range n.AddressFamilyInfo {
-			prefixSent += s.SentPrefixCounter
-		}
-		return &Neighbor{
-			IP:             ip,
-			Connected:      connected,
-			LocalAS:        strconv.Itoa(n.LocalAs),
-			RemoteAS:       strconv.Itoa(n.RemoteAs),
-			PrefixSent:     prefixSent,
-			Port:           n.PortForeign,
-			RemoteRouterID: n.RemoteRouterID,
-			MsgStats:       n.MsgStats,
-		}, nil
+		return neighborFromFRR(k, n), nil
 	}
 	return nil, errors.New("no peers were returned")
 }
@@ -152,32 +186,63 @@ func ParseNeighbours(vtyshRes string) ([]*Neighbor, error) {
 
 	res := make([]*Neighbor, 0)
 	for k, n := range toParse {
-		ip := net.ParseIP(k)
-		if ip == nil {
-			return nil, fmt.Errorf("failed to parse %s as ip", ip)
-		}
-		connected := true
-		if n.BgpState != bgpConnected {
-			connected = false
-		}
-		prefixSent := 0
-		for _, s := range n.AddressFamilyInfo {
-			prefixSent += s.SentPrefixCounter
-		}
-		res = append(res, &Neighbor{
-			IP:             ip,
-			Connected:      connected,
-			LocalAS:        strconv.Itoa(n.LocalAs),
-			RemoteAS:       strconv.Itoa(n.RemoteAs),
-			PrefixSent:     prefixSent,
-			Port:           n.PortForeign,
-			RemoteRouterID: n.RemoteRouterID,
-			MsgStats:       n.MsgStats,
-		})
+		res = append(res, neighborFromFRR(k, n))
 	}
 	return res, nil
 }
 
+// neighborFromFRR converts the neighbor returned by FRR with the given key,
+// which is either its address or its interface for unnumbered neighbors.
+// The IP of an unnumbered neighbor is nil until it gets discovered.
+func neighborFromFRR(key string, n FRRNeighbor) *Neighbor {
+	ip := net.ParseIP(key)
+	iface := ""
+	if ip == nil {
+		iface = key
+		ip = net.ParseIP(n.NeighborAddr)
+	}
+	connected := true
+	if n.BgpState != bgpConnected {
+		connected = false
+	}
+	prefixSent, prefixReceived := 0, 0
+	families := make(map[string]AddressFamilyPrefixes, len(n.AddressFamilyInfo))
+	for f, s := range n.AddressFamilyInfo {
+		prefixSent += s.SentPrefixCounter
+		prefixReceived += s.AcceptedPrefixCounter
+		families[f] = AddressFamilyPrefixes{
+			Sent:        s.SentPrefixCounter,
+			Received:    s.AcceptedPrefixCounter,
+			MaxPrefixes: s.PrefixAllowedMax,
+		}
+	}
+	return &Neighbor{
+		IP:             ip,
+		Interface:      iface,
+		PeerGroup:      n.PeerGroup,
+		Dynamic:        n.DynamicPeer,
+		Connected:      connected,
+		BGPState:       n.BgpState,
+		LocalAS:        strconv.Itoa(n.LocalAs),
+		RemoteAS:       strconv.Itoa(n.RemoteAs),
+		PrefixSent:     prefixSent,
+		PrefixReceived: prefixReceived,
+		Port:           n.PortForeign,
+		RemoteRouterID: n.RemoteRouterID,
+		MsgStats:       n.MsgStats,
+		PrefixCounters: families,
+	}
+}
+
+// Peer returns the interface of the neighbor if it is unnumbered,
+// and its address otherwise.
+func (n *Neighbor) Peer() string {
+	if n.Interface != "" {
+		return n.Interface
+	}
+	return n.IP.String()
+}
+
 // parseRoute takes the result of a show bgp ipv4 / ipv6
 // and parses the informations related to all the routes.
 func ParseRoutes(vtyshRes string) (map[string]Route, error) {
@@ -199,9 +264,17 @@ func ParseRoutes(vtyshRes string) (map[string]Route, error) {
 			NextHops:    make([]net.IP, 0),
 		}
 		for _, n := range frrRoutes {
-			r.LocalPref = n.LocalPref
-			r.Origin = n.Origin
-		out:
+			path := RoutePath{
+				PeerID:    n.PeerID,
+				NextHops:  make([]net.IP, 0),
+				LocalPref: n.LocalPref,
+				Origin:    n.Origin,
+				MED:       n.MED,
+				ASPath:    n.Path,
+				Valid:     n.Valid,
+				BestPath:  n.BestPath,
+				Multipath: n.Multipath,
+			}
 			for _, h := range n.Nexthops {
 				ip := net.ParseIP(h.IP)
 				if ip == nil {
@@ -210,19 +283,202 @@ func ParseRoutes(vtyshRes string) (map[string]Route, error) {
 				if ip.To4() == nil && h.Scope == "link-local" {
 					continue
 				}
-				for _, current := range r.NextHops {
+				path.NextHops = append(path.NextHops, ip)
+				if !containsIP(r.NextHops, ip) {
+					r.NextHops = append(r.NextHops, ip)
+				}
+			}
+			r.Paths = append(r.Paths, path)
+		}
+		if best := bestPath(r.Paths); best != nil {
+			r.LocalPref = best.LocalPref
+			r.Origin = best.Origin
+			r.MED = best.MED
+			r.ASPath = best.ASPath
+		}
+		res[destIP.String()] = r
+	}
+	return res, nil
+}
+
+// EVPNRoute is a route of the l2vpn evpn table.
+type EVPNRoute struct {
+	// RD is the route distinguisher of the route.
+	RD string
+	// Prefix is the EVPN prefix of the route, i.e. [5]:[0]:[24]:[192.168.1.0].
+	Prefix string
+	// Type is the EVPN route type, i.e. 2 for the MAC/IP advertisements
+	// and 5 for the IP prefixes.
+	Type int
+	// MAC is set only for the MAC/IP advertisement routes.
+	MAC string
+	// IP is the address (or the prefix address for type-5 routes) carried
+	// by the route, if any.
+	IP       net.IP
+	VNI      int
+	NextHops []net.IP
+	// RouteTargets are the route targets the route is tagged with, in the
+	// AS:value form.
+	RouteTargets []string
+}
+
+type frrEVPNPath struct {
+	Valid             bool   `json:"valid"`
+	RouteType         int    `json:"routeType"`
+	MAC               string `json:"mac"`
+	IP                string `json:"ip"`
+	VNI               int    `json:"vni"`
+	ExtendedCommunity struct {
+		String string `json:"string"`
+	} `json:"extendedCommunity"`
+	Nexthops []struct {
+		IP string `json:"ip"`
+	} `json:"nexthops"`
+}
+
+type frrEVPNPrefix struct {
+	Prefix string `json:"prefix"`
+	// Paths is a list of lists of paths, each containing a single path.
+	Paths [][]frrEVPNPath `json:"paths"`
+}
+
+// ParseEVPNRoutes takes the result of a show bgp l2vpn evpn json
+// and parses the informations related to all the routes, sorted
+// by route distinguisher and prefix. Only the valid paths are considered.
+func ParseEVPNRoutes(vtyshRes string) ([]EVPNRoute, error) {
+	toParse := map[string]json.RawMessage{}
+	err := json.Unmarshal([]byte(vtyshRes), &toParse)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse vtysh response")
+	}
+
+	res := make([]EVPNRoute, 0)
+	for rd, raw := range toParse {
+		// The routes are grouped by route distinguisher, next to scalar
+		// fields such as the local AS which are skipped.
+		byPrefix := map[string]json.RawMessage{}
+		if err := json.Unmarshal(raw, &byPrefix); err != nil {
+			continue
+		}
+		for k, rawPrefix := range byPrefix {
+			if k == "rd" {
+				continue
+			}
+			p := frrEVPNPrefix{}
+			if err := json.Unmarshal(rawPrefix, &p); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse evpn prefix %s", k)
+			}
+			r, err := evpnRouteFromFRR(rd, p)
+			if err != nil {
+				return nil, err
+			}
+			if r != nil {
+				res = append(res, *r)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].RD != res[j].RD {
+			return res[i].RD < res[j].RD
+		}
+		return res[i].Prefix < res[j].Prefix
+	})
+	return res, nil
+}
+
+// evpnRouteFromFRR merges the valid paths of the given prefix in a single
+// route, returning nil if none is valid.
+func evpnRouteFromFRR(rd string, p frrEVPNPrefix) (*EVPNRoute, error) {
+	var res *EVPNRoute
+	for _, paths := range p.Paths {
+		for _, path := range paths {
+			if !path.Valid {
+				continue
+			}
+			if res == nil {
+				routeType := path.RouteType
+				if routeType == 0 {
+					routeType = evpnRouteType(p.Prefix)
+				}
+				res = &EVPNRoute{
+					RD:       rd,
+					Prefix:   p.Prefix,
+					Type:     routeType,
+					MAC:      path.MAC,
+					IP:       net.ParseIP(path.IP),
+					VNI:      path.VNI,
+					NextHops: make([]net.IP, 0),
+				}
+			}
+		out:
+			for _, h := range path.Nexthops {
+				ip := net.ParseIP(h.IP)
+				if ip == nil {
+					return nil, fmt.Errorf("failed to parse ip %s", h.IP)
+				}
+				for _, current := range res.NextHops {
 					if ip.Equal(current) {
 						continue out
 					}
 				}
-				r.NextHops = append(r.NextHops, ip)
+				res.NextHops = append(res.NextHops, ip)
+			}
+			for _, c := range strings.Fields(path.ExtendedCommunity.String) {
+				if rt := strings.TrimPrefix(c, "RT:"); rt != c && !containsString(res.RouteTargets, rt) {
+					res.RouteTargets = append(res.RouteTargets, rt)
+				}
 			}
 		}
-		res[destIP.String()] = r
 	}
 	return res, nil
 }
 
+// evpnRouteType returns the route type encoded as the first field of
+// the given EVPN prefix, i.e. 5 for [5]:[0]:[24]:[192.168.1.0].
+func evpnRouteType(prefix string) int {
+	end := strings.Index(prefix, "]")
+	if !strings.HasPrefix(prefix, "[") || end < 0 {
+		return 0
+	}
+	res, err := strconv.Atoi(prefix[1:end])
+	if err != nil {
+		return 0
+	}
+	return res
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// bestPath returns the best of the given paths, falling back to the first
+// one when FRR marks none of them as best. It returns nil if there are none.
+func bestPath(paths []RoutePath) *RoutePath {
+	for i := range paths {
+		if paths[i].BestPath {
+			return &paths[i]
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	return &paths[0]
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, i := range ips {
+		if i.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
 func ParseBFDPeers(vtyshRes string) ([]BFDPeer, error) {
 	parseRes := []BFDPeer{}
 	err := json.Unmarshal([]byte(vtyshRes), &parseRes)
@@ -76,13 +76,15 @@ func TestNeighbour(t *testing.T) {
         "ipv4Unicast":{
           "routerAlwaysNextHop":true,
           "commAttriSentToNbr":"extendedAndStandard",
-          "acceptedPrefixCounter":0,
+          "acceptedPrefixCounter":3,
           "sentPrefixCounter":%d
         },
         "ipv6Unicast":{
           "routerAlwaysNextHop":true,
           "commAttriSentToNbr":"extendedAndStandard",
-          "acceptedPrefixCounter":0,
+          "acceptedPrefixCounter":2,
+          "prefixAllowedMax":10,
+          "prefixAllowedWarningThresh":75,
           "sentPrefixCounter":%d
         }
       },
@@ -169,6 +171,12 @@ func TestNeighbour(t *testing.T) {
 			if tt.ipv4PrefixSent+tt.ipv6PrefixSent != n.PrefixSent {
 				t.Fatal("Expected prefix sent", tt.ipv4PrefixSent+tt.ipv6PrefixSent, "got", n.PrefixSent)
 			}
+			if n.PrefixReceived != 5 {
+				t.Fatal("Expected prefix received", 5, "got", n.PrefixReceived)
+			}
+			if n.BGPState != tt.status {
+				t.Fatal("Expected bgp state", tt.status, "got", n.BGPState)
+			}
 			if tt.port != n.Port {
 				t.Fatal("Expected port", tt.port, "got", n.Port)
 			}
@@ -178,6 +186,13 @@ func TestNeighbour(t *testing.T) {
 			if !cmp.Equal(expectedStats, n.MsgStats) {
 				t.Fatal("unexpected BGP messages stats (-want +got)\n", cmp.Diff(expectedStats, n.MsgStats))
 			}
+			expectedCounters := map[string]AddressFamilyPrefixes{
+				"ipv4Unicast": {Sent: tt.ipv4PrefixSent, Received: 3},
+				"ipv6Unicast": {Sent: tt.ipv6PrefixSent, Received: 2, MaxPrefixes: 10},
+			}
+			if !cmp.Equal(expectedCounters, n.PrefixCounters) {
+				t.Fatal("unexpected prefix counters (-want +got)\n", cmp.Diff(expectedCounters, n.PrefixCounters))
+			}
 		})
 	}
 }
@@ -519,6 +534,86 @@ func TestNeighbours(t *testing.T) {
 	}
 }
 
+const unnumberedNeighbors = `{
+  "eth1":{
+    "bgpNeighborAddr":"fe80::5054:ff:fe12:3456",
+    "remoteAs":65001,
+    "localAs":64512,
+    "nbrExternalLink":true,
+    "hostname":"leaf1",
+    "remoteRouterId":"10.0.0.1",
+    "bgpVersion":4,
+    "bgpState":"Established",
+    "portForeign":179,
+    "addressFamilyInfo":{
+      "ipv4Unicast":{
+        "acceptedPrefixCounter":2,
+        "sentPrefixCounter":1
+      }
+    }
+  },
+  "eth2":{
+    "remoteAs":0,
+    "localAs":64512,
+    "bgpVersion":4,
+    "bgpState":"Idle"
+  }
+}`
+
+func TestUnnumberedNeighbours(t *testing.T) {
+	nn, err := ParseNeighbours(unnumberedNeighbors)
+	if err != nil {
+		t.Fatalf("Failed to parse %s", err)
+	}
+	if len(nn) != 2 {
+		t.Fatalf("Expected 2 neighbours, got %d", len(nn))
+	}
+	sort.Slice(nn, func(i, j int) bool {
+		return nn[i].Interface < nn[j].Interface
+	})
+
+	if nn[0].Interface != "eth1" || nn[0].Peer() != "eth1" {
+		t.Fatalf("expected neighbour on eth1, got %s", nn[0].Peer())
+	}
+	if !nn[0].IP.Equal(net.ParseIP("fe80::5054:ff:fe12:3456")) {
+		t.Fatalf("neighbour ip not matching, got %s", nn[0].IP)
+	}
+	if !nn[0].Connected || nn[0].PrefixReceived != 2 {
+		t.Fatalf("unexpected neighbour state %+v", nn[0])
+	}
+	if nn[1].Interface != "eth2" || nn[1].IP != nil {
+		t.Fatalf("expected neighbour on eth2 without ip, got %s %s", nn[1].Interface, nn[1].IP)
+	}
+}
+
+const dynamicNeighbors = `{
+  "10.1.0.5":{
+    "remoteAs":65010,
+    "localAs":64512,
+    "peerGroup":"vms",
+    "dynamicPeer":true,
+    "bgpVersion":4,
+    "bgpState":"Established",
+    "portForeign":40000
+  }
+}`
+
+func TestDynamicNeighbours(t *testing.T) {
+	nn, err := ParseNeighbours(dynamicNeighbors)
+	if err != nil {
+		t.Fatalf("Failed to parse %s", err)
+	}
+	if len(nn) != 1 {
+		t.Fatalf("Expected 1 neighbour, got %d", len(nn))
+	}
+	if !nn[0].IP.Equal(net.ParseIP("10.1.0.5")) {
+		t.Fatalf("neighbour ip not matching, got %s", nn[0].IP)
+	}
+	if !nn[0].Dynamic || nn[0].PeerGroup != "vms" {
+		t.Fatalf("expected dynamic neighbour of group vms, got dynamic %v group %s", nn[0].Dynamic, nn[0].PeerGroup)
+	}
+}
+
 const routes = `{
   "vrfId": 0,
   "vrfName": "default",
@@ -617,6 +712,289 @@ func TestRoutes(t *testing.T) {
 	}
 }
 
+const routesWithAttributes = `{
+  "vrfId": 0,
+  "vrfName": "default",
+  "tableVersion": 3,
+  "routerId": "172.18.0.5",
+  "defaultLocPrf": 100,
+  "localAS": 64512,
+  "routes": { "192.168.10.0/24": [
+   {
+     "valid":true,
+     "bestpath":true,
+     "pathFrom":"external",
+     "prefix":"192.168.10.0",
+     "prefixLen":24,
+     "network":"192.168.10.0\/24",
+     "metric":150,
+     "weight":0,
+     "peerId":"172.18.0.4",
+     "path":"64513 64513 64513",
+     "origin":"IGP",
+     "nexthops":[
+       {
+         "ip":"172.18.0.4",
+         "afi":"ipv4",
+         "used":true
+       }
+     ]
+   }
+ ] }  }`
+
+func TestRoutesAttributes(t *testing.T) {
+	rr, err := ParseRoutes(routesWithAttributes)
+	if err != nil {
+		t.Fatalf("Failed to parse %s", err)
+	}
+
+	r, ok := rr["192.168.10.0"]
+	if !ok {
+		t.Fatalf("Routes for 192.168.10.0/24 not found")
+	}
+	if r.MED != 150 {
+		t.Fatalf("expected med 150, got %d", r.MED)
+	}
+	if r.Origin != "IGP" {
+		t.Fatalf("expected origin IGP, got %s", r.Origin)
+	}
+	if r.ASPath != "64513 64513 64513" {
+		t.Fatalf("expected as path 64513 64513 64513, got %s", r.ASPath)
+	}
+}
+
+const routesMultipath = `{
+  "vrfId": 0,
+  "vrfName": "default",
+  "tableVersion": 4,
+  "routerId": "172.18.0.5",
+  "defaultLocPrf": 100,
+  "localAS": 64512,
+  "routes": { "192.168.20.0/24": [
+   {
+     "valid":true,
+     "bestpath":true,
+     "multipath":true,
+     "pathFrom":"external",
+     "prefix":"192.168.20.0",
+     "prefixLen":24,
+     "network":"192.168.20.0\/24",
+     "metric":10,
+     "weight":0,
+     "peerId":"172.18.0.3",
+     "path":"64514",
+     "origin":"IGP",
+     "nexthops":[
+       {
+         "ip":"172.18.0.3",
+         "afi":"ipv4",
+         "used":true
+       }
+     ]
+   },
+   {
+     "valid":true,
+     "multipath":true,
+     "pathFrom":"external",
+     "prefix":"192.168.20.0",
+     "prefixLen":24,
+     "network":"192.168.20.0\/24",
+     "metric":0,
+     "weight":0,
+     "peerId":"172.18.0.4",
+     "path":"64513",
+     "origin":"IGP",
+     "nexthops":[
+       {
+         "ip":"172.18.0.4",
+         "afi":"ipv4",
+         "used":true
+       }
+     ]
+   }
+ ] }  }`
+
+func TestRoutesMultipath(t *testing.T) {
+	rr, err := ParseRoutes(routesMultipath)
+	if err != nil {
+		t.Fatalf("Failed to parse %s", err)
+	}
+
+	r, ok := rr["192.168.20.0"]
+	if !ok {
+		t.Fatalf("Routes for 192.168.20.0/24 not found")
+	}
+	expected := []RoutePath{
+		{
+			PeerID:    "172.18.0.3",
+			NextHops:  []net.IP{net.ParseIP("172.18.0.3")},
+			Origin:    "IGP",
+			MED:       10,
+			ASPath:    "64514",
+			Valid:     true,
+			BestPath:  true,
+			Multipath: true,
+		},
+		{
+			PeerID:    "172.18.0.4",
+			NextHops:  []net.IP{net.ParseIP("172.18.0.4")},
+			Origin:    "IGP",
+			ASPath:    "64513",
+			Valid:     true,
+			Multipath: true,
+		},
+	}
+	if !cmp.Equal(expected, r.Paths) {
+		t.Fatal("unexpected paths (-want +got)\n", cmp.Diff(expected, r.Paths))
+	}
+	if len(r.NextHops) != 2 {
+		t.Fatalf("expected 2 next hops, got %v", r.NextHops)
+	}
+	if r.ASPath != "64514" || r.MED != 10 {
+		t.Fatalf("expected the attributes of the best path, got as path %s and med %d", r.ASPath, r.MED)
+	}
+}
+
+const evpnRoutes = `{
+  "bgpTableVersion":3,
+  "bgpLocalRouterId":"172.18.0.5",
+  "defaultLocPrf":100,
+  "localAS":64512,
+  "172.18.0.5:2":{
+    "rd":"172.18.0.5:2",
+    "[2]:[0]:[48]:[aa:bb:cc:dd:ee:ff]":{
+      "prefix":"[2]:[0]:[48]:[aa:bb:cc:dd:ee:ff]",
+      "prefixLen":352,
+      "paths":[
+        [
+          {
+            "valid":true,
+            "bestpath":true,
+            "pathFrom":"external",
+            "routeType":2,
+            "ethTag":0,
+            "macLen":48,
+            "mac":"aa:bb:cc:dd:ee:ff",
+            "ipLen":0,
+            "weight":32768,
+            "peerId":"(unspec)",
+            "path":"",
+            "origin":"IGP",
+            "extendedCommunity":{
+              "string":"RT:64512:100 ET:8"
+            },
+            "nexthops":[
+              {
+                "ip":"172.18.0.5",
+                "afi":"ipv4",
+                "used":true
+              }
+            ]
+          }
+        ]
+      ]
+    }
+  },
+  "172.18.0.6:3":{
+    "rd":"172.18.0.6:3",
+    "[5]:[0]:[24]:[192.168.10.0]":{
+      "prefix":"[5]:[0]:[24]:[192.168.10.0]",
+      "prefixLen":352,
+      "paths":[
+        [
+          {
+            "valid":true,
+            "bestpath":true,
+            "pathFrom":"external",
+            "ip":"192.168.10.0",
+            "peerId":"172.18.0.6",
+            "path":"64513",
+            "origin":"IGP",
+            "extendedCommunity":{
+              "string":"RT:64513:1 ET:8 Rmac:aa:bb:cc:00:00:01"
+            },
+            "nexthops":[
+              {
+                "ip":"172.18.0.6",
+                "afi":"ipv4",
+                "used":true
+              }
+            ]
+          }
+        ],
+        [
+          {
+            "valid":true,
+            "pathFrom":"external",
+            "ip":"192.168.10.0",
+            "peerId":"172.18.0.7",
+            "path":"64513",
+            "origin":"IGP",
+            "extendedCommunity":{
+              "string":"RT:64513:1 RT:64513:2 ET:8"
+            },
+            "nexthops":[
+              {
+                "ip":"172.18.0.6",
+                "afi":"ipv4",
+                "used":true
+              }
+            ]
+          }
+        ],
+        [
+          {
+            "valid":false,
+            "ip":"192.168.10.0",
+            "peerId":"172.18.0.8",
+            "extendedCommunity":{
+              "string":"RT:64513:3"
+            },
+            "nexthops":[
+              {
+                "ip":"172.18.0.8",
+                "afi":"ipv4",
+                "used":true
+              }
+            ]
+          }
+        ]
+      ]
+    }
+  },
+  "numPrefix":2,
+  "totalPrefix":2
+}`
+
+func TestEVPNRoutes(t *testing.T) {
+	routes, err := ParseEVPNRoutes(evpnRoutes)
+	if err != nil {
+		t.Fatalf("Failed to parse %s", err)
+	}
+
+	expected := []EVPNRoute{
+		{
+			RD:           "172.18.0.5:2",
+			Prefix:       "[2]:[0]:[48]:[aa:bb:cc:dd:ee:ff]",
+			Type:         2,
+			MAC:          "aa:bb:cc:dd:ee:ff",
+			NextHops:     []net.IP{net.ParseIP("172.18.0.5")},
+			RouteTargets: []string{"64512:100"},
+		},
+		{
+			RD:           "172.18.0.6:3",
+			Prefix:       "[5]:[0]:[24]:[192.168.10.0]",
+			Type:         5,
+			IP:           net.ParseIP("192.168.10.0"),
+			NextHops:     []net.IP{net.ParseIP("172.18.0.6")},
+			RouteTargets: []string{"64513:1", "64513:2"},
+		},
+	}
+	if !cmp.Equal(routes, expected) {
+		t.Fatalf("unexpected evpn routes: %s", cmp.Diff(routes, expected))
+	}
+}
+
 const bfdPeers = `[
    {
       "multihop":false,